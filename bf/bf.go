@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"math/big"
 	"sort"
 	"strconv"
 	"strings"
@@ -25,6 +26,35 @@ func Solve(f Formula) map[string]bool {
 	return asCnf(f).solve()
 }
 
+// Count returns the number of models of f, i.e the number of assignments of the variables of f that satisfy it.
+// Dummy variables, introduced when converting f to CNF, are not taken into account.
+func Count(f Formula) *big.Int {
+	cnf := asCnf(f)
+	return solver.New(cnf.problem()).CountProjected(cnf.vars.projection())
+}
+
+// Enumerate returns the number of models of f, i.e the number of assignments of the variables of f that satisfy it.
+// If models is non-nil, each model is written on it as soon as it is found, as a map associating each variable name with its binding.
+// Dummy variables, introduced when converting f to CNF, are neither part of models nor taken into account
+// when counting, so that no two models differ only on dummy variables.
+// models will be closed at the end of the function.
+func Enumerate(f Formula, models chan map[string]bool) int {
+	cnf := asCnf(f)
+	s := solver.New(cnf.problem())
+	if models == nil {
+		return s.EnumerateProjected(cnf.vars.projection(), nil, nil)
+	}
+	defer close(models)
+	ch := make(chan []bool)
+	go s.EnumerateProjected(cnf.vars.projection(), ch, nil)
+	nb := 0
+	for m := range ch {
+		nb++
+		models <- cnf.vars.model(m)
+	}
+	return nb
+}
+
 // Dimacs writes the DIMACS CNF version of the formula on w.
 // It is useful so as to feed it to any SAT solver.
 // The original names of variables is associated with their DIMACS integer counterparts
@@ -398,6 +428,29 @@ func (vars *vars) litValue(l lit) int {
 	return val
 }
 
+// projection returns the solver vars associated with non-dummy variables, sorted by index.
+func (vars *vars) projection() []solver.Var {
+	var res []solver.Var
+	for v, idx := range vars.pb {
+		if !v.dummy {
+			res = append(res, solver.IntToVar(int32(idx)))
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
+// model returns the binding of each non-dummy variable in the given solver model.
+func (vars *vars) model(m []bool) map[string]bool {
+	res := make(map[string]bool)
+	for v, idx := range vars.pb {
+		if !v.dummy {
+			res[v.name] = m[idx-1]
+		}
+	}
+	return res
+}
+
 // Dummy creates a dummy variable and returns its associated index.
 func (vars *vars) dummy() int {
 	val := len(vars.all) + 1
@@ -417,8 +470,7 @@ type cnf struct {
 // If it is satisfiable, the function returns a model, associating each variable name with its binding.
 // Else, the function returns nil.
 func (cnf *cnf) solve() map[string]bool {
-	pb := solver.ParseSlice(cnf.clauses)
-	s := solver.New(pb)
+	s := solver.New(cnf.problem())
 	if s.Solve() != solver.Sat {
 		return nil
 	}
@@ -430,6 +482,11 @@ func (cnf *cnf) solve() map[string]bool {
 	return vars
 }
 
+// problem returns the solver problem associated with cnf.
+func (cnf *cnf) problem() *solver.Problem {
+	return solver.ParseSliceNb(cnf.clauses, len(cnf.vars.all))
+}
+
 // asCnf returns a CNF representation of the given formula.
 func asCnf(f Formula) *cnf {
 	vars := vars{all: make(map[variable]int), pb: make(map[variable]int)}
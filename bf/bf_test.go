@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"testing"
+
+	"github.com/crillab/gophersat/explain"
+	"github.com/crillab/gophersat/solver"
 )
 
 func TestMultipleTimesSameVariable(t *testing.T) {
@@ -322,3 +325,83 @@ func TestPanic2(t *testing.T) {
 		t.Errorf("should be exactly two vars")
 	}
 }
+
+func TestCountAndEnumerate(t *testing.T) {
+	// Or of ands introduces dummy variables, that must not yield duplicate models.
+	f := Or(And(Var("a"), Var("b")), And(Not(Var("a")), Var("c")))
+	if nb := Count(f); nb.Int64() != 4 {
+		t.Errorf("expected 4 models, got %v", nb)
+	}
+	models := make(chan map[string]bool)
+	go Enumerate(f, models)
+	seen := make(map[string]bool)
+	for model := range models {
+		if len(model) != 3 {
+			t.Errorf("expected 3 vars in model, got %v", model)
+		}
+		if !f.Eval(model) {
+			t.Errorf("invalid model %v", model)
+		}
+		key := fmt.Sprint(model)
+		if seen[key] {
+			t.Errorf("duplicate model %v", model)
+		}
+		seen[key] = true
+	}
+	if len(seen) != 4 {
+		t.Errorf("expected 4 models, got %d", len(seen))
+	}
+	if nb := Enumerate(Unique("a", "b", "c", "d", "e", "f", "g"), nil); nb != 7 {
+		t.Errorf("expected 7 models, got %d", nb)
+	}
+	if nb := Count(And(Var("a"), Not(Var("a")))); nb.Sign() != 0 {
+		t.Errorf("expected 0 models, got %v", nb)
+	}
+}
+
+func TestWhyImplied(t *testing.T) {
+	constraints := map[string]Formula{
+		"":                   Unique("red", "blue", "green"),
+		"no red convertible": Implies(Var("convertible"), Not(Var("red"))),
+		"sport is blue":      Implies(Var("sport"), Var("blue")),
+		"sport has 4 doors":  Implies(Var("sport"), Not(Var("convertible"))),
+	}
+	names, assumed, err := WhyImplied(constraints, Not(Var("green")), []Formula{Not(Var("convertible")), Var("sport")})
+	if err != nil {
+		t.Fatalf("could not explain implied literal: %v", err)
+	}
+	if fmt.Sprint(names) != "[sport is blue]" || fmt.Sprint(assumed) != "[sport]" {
+		t.Errorf("invalid explanation: constraints %q, assumptions %v", names, assumed)
+	}
+	if _, _, err := WhyImplied(constraints, Var("convertible"), []Formula{Var("red")}); err != explain.ErrNotImplied {
+		t.Errorf("expected ErrNotImplied, got %v", err)
+	}
+	if _, _, err := WhyImplied(constraints, And(Var("red"), Var("blue")), nil); err == nil {
+		t.Errorf("expected an error for non-literal")
+	}
+}
+
+func TestInterpolant(t *testing.T) {
+	a := And(Var("x"), Implies(Var("x"), Var("y")), Implies(Var("y"), Or(Var("z"), Var("t"))))
+	b := And(Not(Var("z")), Not(Var("t")), Var("u"))
+	for _, system := range []solver.InterpolationSystem{solver.McMillan, solver.Pudlak} {
+		itp, err := Interpolant(a, b, system)
+		if err != nil {
+			t.Fatalf("could not compute interpolant: %v", err)
+		}
+		if model := Solve(And(a, Not(itp))); model != nil {
+			t.Errorf("interpolant %v is not implied by a: model %v", itp, model)
+		}
+		if model := Solve(And(itp, b)); model != nil {
+			t.Errorf("interpolant %v is consistent with b: model %v", itp, model)
+		}
+		for _, name := range []string{"x", "y", "u"} {
+			if itp.Eval(map[string]bool{"z": true, "t": false, name: true}) != itp.Eval(map[string]bool{"z": true, "t": false}) {
+				t.Errorf("interpolant %v depends on non-shared var %q", itp, name)
+			}
+		}
+	}
+	if _, err := Interpolant(a, Var("z"), solver.McMillan); err == nil {
+		t.Errorf("expected an error for satisfiable conjunction")
+	}
+}
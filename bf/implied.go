@@ -0,0 +1,59 @@
+package bf
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/crillab/gophersat/explain"
+)
+
+// WhyImplied explains why l, a variable or a negated variable, is implied by the given constraints
+// under the given assumptions, i.e why l is true in all models of all the constraints where all assumptions,
+// also variables or negated variables, are true.
+// constraints associates each constraint with its name. The constraint named "", if any, is hard:
+// it is used to prove l is implied, but is never part of the explanation.
+// The function returns a minimal set of constraints, by increasing name, and assumptions that force l.
+// If l is not implied, the function returns explain.ErrNotImplied.
+func WhyImplied(constraints map[string]Formula, l Formula, assumptions []Formula) (names []string, assumed []Formula, err error) {
+	names = make([]string, 0, len(constraints))
+	for name := range constraints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	vars := vars{all: make(map[variable]int), pb: make(map[variable]int)}
+	var pb explain.Problem
+	for _, name := range names {
+		pb.AddGroup(name, cnfRec(constraints[name].nnf(), &vars))
+	}
+	target, err := litValue(l, &vars)
+	if err != nil {
+		return nil, nil, err
+	}
+	lits := make([]int, len(assumptions))
+	for i, a := range assumptions {
+		if lits[i], err = litValue(a, &vars); err != nil {
+			return nil, nil, err
+		}
+	}
+	pb.NbVars = len(vars.all) // Some vars might only appear in l or in assumptions
+	names, used, err := pb.WhyImplied(target, lits)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := 0; len(used) > 0; i++ { // used is a subsequence of lits
+		if lits[i] == used[0] {
+			assumed = append(assumed, assumptions[i])
+			used = used[1:]
+		}
+	}
+	return names, assumed, nil
+}
+
+// litValue returns the int value associated with f, which must be a variable or a negated variable.
+func litValue(f Formula, vars *vars) (int, error) {
+	l, ok := f.nnf().(lit)
+	if !ok {
+		return 0, fmt.Errorf("%v is not a literal", f)
+	}
+	return vars.litValue(l), nil
+}
@@ -0,0 +1,50 @@
+package bf
+
+import "github.com/crillab/gophersat/solver"
+
+// Interpolant returns a Craig interpolant for a and b, whose conjunction must be unsatisfiable,
+// i.e a formula that only contains variables that appear in both a and b, that is implied by a,
+// and whose conjunction with b is unsatisfiable.
+// The interpolant is computed from a refutation of a ∧ b, with the given interpolation system.
+// Since sub-formulas can be shared in the interpolant, its string representation can be much larger than its DAG.
+// If a ∧ b is satisfiable, the function returns an error.
+func Interpolant(a, b Formula, system solver.InterpolationSystem) (Formula, error) {
+	vars := vars{all: make(map[variable]int), pb: make(map[variable]int)}
+	clausesA := cnfRec(a.nnf(), &vars)
+	clausesB := cnfRec(b.nnf(), &vars)
+	g, err := solver.Interpolant(clausesA, clausesB, system)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(vars.pb))
+	for v, idx := range vars.pb {
+		names[idx] = v.name
+	}
+	return gateFormula(g, names, make(map[*solver.Gate]Formula)), nil
+}
+
+// gateFormula returns the formula associated with the given gate, where each var index is associated with its name.
+// Formulas associated with gates that were already translated are stored in memo, so they are shared.
+func gateFormula(g *solver.Gate, names map[int]string, memo map[*solver.Gate]Formula) Formula {
+	if f, ok := memo[g]; ok {
+		return f
+	}
+	var f Formula
+	switch g.Op {
+	case solver.GateFalse:
+		f = False
+	case solver.GateTrue:
+		f = True
+	case solver.GateLit:
+		f = Var(names[int(g.Lit.Var().Int())])
+		if !g.Lit.IsPositive() {
+			f = Not(f)
+		}
+	case solver.GateAnd:
+		f = And(gateFormula(g.Inputs[0], names, memo), gateFormula(g.Inputs[1], names, memo))
+	default:
+		f = Or(gateFormula(g.Inputs[0], names, memo), gateFormula(g.Inputs[1], names, memo))
+	}
+	memo[g] = f
+	return f
+}
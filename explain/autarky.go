@@ -0,0 +1,130 @@
+package explain
+
+import (
+	"fmt"
+
+	"github.com/crillab/gophersat/solver"
+)
+
+// MaxAutarky returns the maximal autarky of the problem, as a slice of DIMACS literals.
+// An autarky is a partial assignment such that each clause that contains an assigned var is satisfied:
+// the clauses it touches can thus be removed without changing the satisfiability of the problem.
+// Clauses touched by the maximal autarky are exactly the clauses that are touched by at least one autarky.
+// If the problem is satisfiable, any of its models is an autarky, and the maximal autarky touches all clauses.
+// The autarky is computed through iterated SAT calls, each call looking for an autarky that touches
+// clauses that were not touched yet, all autarkies being composed afterwards.
+func (pb *Problem) MaxAutarky() []int {
+	model := pb.maxAutarky()
+	var lits []int
+	for i, val := range model {
+		if val > 0 {
+			lits = append(lits, i+1)
+		} else if val < 0 {
+			lits = append(lits, -i-1)
+		}
+	}
+	return lits
+}
+
+// LeanKernel returns the lean kernel of the problem, i.e the set of clauses that can appear in some resolution refutation.
+// It is the set of clauses that are not touched by the maximal autarky, as defined by MaxAutarky.
+// All MUSes are part of the lean kernel, so clauses outside of it can be removed before extracting a MUS,
+// whatever the MUS.
+// Computing the lean kernel only takes a few SAT calls, so it is usually a cheap first step of inconsistency analysis.
+// If the problem is satisfiable, its lean kernel is empty and the method returns ErrNotUnsat.
+func (pb *Problem) LeanKernel() (kernel *Problem, err error) {
+	model := pb.maxAutarky()
+	var indices []int
+	for i, clause := range pb.Clauses {
+		if !touched(clause, model) {
+			indices = append(indices, i)
+		}
+	}
+	if len(indices) == 0 {
+		return nil, ErrNotUnsat
+	}
+	return pb.subset(indices), nil
+}
+
+// touched returns true iff the clause contains a var that is bound in the partial model,
+// where each var is associated with 1 (true), -1 (false) or 0 (unbound).
+func touched(clause []int, model []int) bool {
+	for _, lit := range clause {
+		if lit < 0 {
+			lit = -lit
+		}
+		if model[lit-1] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// maxAutarky returns the maximal autarky of the problem, as a partial model.
+// In the encoding, var x is associated with two solver vars, telling whether x is assigned to true and to false,
+// respectively, and each clause c is associated with a var t_c telling whether c is touched.
+// A touched clause must contain a lit assigned to true, and if any var of c is assigned, c is touched.
+func (pb *Problem) maxAutarky() []int {
+	nbVars := pb.NbVars
+	trueLit := func(lit int) int { // The var telling whether lit is assigned to true
+		if lit > 0 {
+			return lit
+		}
+		return nbVars - lit
+	}
+	var clauses [][]int
+	for v := 1; v <= nbVars; v++ {
+		clauses = append(clauses, []int{-v, -(nbVars + v)})
+	}
+	for i, clause := range pb.Clauses {
+		t := 2*nbVars + i + 1
+		sat := make([]int, 1, len(clause)+1)
+		sat[0] = -t
+		for _, lit := range clause {
+			sat = append(sat, trueLit(lit))
+			clauses = append(clauses, []int{-trueLit(lit), t}, []int{-trueLit(-lit), t})
+		}
+		clauses = append(clauses, sat)
+	}
+	s := solver.New(solver.ParseSliceNb(clauses, 2*nbVars+len(pb.Clauses)))
+	model := make([]int, nbVars)
+	isTouched := make([]bool, len(pb.Clauses))
+	for nb := 1; ; nb++ {
+		// At least one clause that is not touched yet must be touched
+		var lits []solver.Lit
+		for i, t := range isTouched {
+			if !t {
+				lits = append(lits, solver.IntToLit(int32(2*nbVars+i+1)))
+			}
+		}
+		if len(lits) == 0 {
+			return model
+		}
+		s.AppendClause(solver.NewClause(lits))
+		if s.Solve() != solver.Sat {
+			return model
+		}
+		autarky := s.Model()
+		// Compose current autarky with the new one
+		for v := range model {
+			if model[v] != 0 {
+				continue
+			}
+			if autarky[v] {
+				model[v] = 1
+			} else if autarky[nbVars+v] {
+				model[v] = -1
+			}
+		}
+		nbTouched := 0
+		for i, clause := range pb.Clauses {
+			if !isTouched[i] && touched(clause, model) {
+				isTouched[i] = true
+				nbTouched++
+			}
+		}
+		if pb.Options.Verbose {
+			fmt.Printf("c autarky #%d touches %d more clauses\n", nb, nbTouched)
+		}
+	}
+}
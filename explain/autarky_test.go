@@ -0,0 +1,63 @@
+package explain
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/crillab/gophersat/solver"
+)
+
+func TestLeanKernel(t *testing.T) {
+	pb := makeMus(4, [][]int{{1, 2}, {-1, 2}, {-2}, {2, 3}, {3, 4}, {1, -4}})
+	kernel, err := pb.LeanKernel()
+	if err != nil {
+		t.Fatalf("could not compute lean kernel: %v", err)
+	}
+	if res := fmt.Sprint(kernel.Clauses); res != "[[1 2] [-1 2] [-2]]" {
+		t.Errorf("invalid lean kernel %v", res)
+	}
+	autarky := pb.MaxAutarky()
+	model := make([]int, pb.NbVars)
+	for _, lit := range autarky {
+		if lit > 0 {
+			model[lit-1] = 1
+		} else {
+			model[-lit-1] = -1
+		}
+	}
+	for _, clause := range pb.Clauses {
+		if !touched(clause, model) {
+			continue
+		}
+		sat := false
+		for _, lit := range clause {
+			sat = sat || (lit > 0 && model[lit-1] == 1) || (lit < 0 && model[-lit-1] == -1)
+		}
+		if !sat {
+			t.Errorf("%v is not an autarky: clause %v is touched but not satisfied", autarky, clause)
+		}
+	}
+	if _, err := makeMus(2, [][]int{{1, 2}, {-1}}).LeanKernel(); err != ErrNotUnsat {
+		t.Errorf("expected ErrNotUnsat, got %v", err)
+	}
+}
+
+func TestLeanKernelFile(t *testing.T) {
+	cnf, err := os.Open("testcnf/50.cnf")
+	if err != nil {
+		t.Fatalf("could not read CNF file: %v", err)
+	}
+	defer cnf.Close()
+	pb, err := ParseCNF(cnf)
+	if err != nil {
+		t.Fatalf("could not parse cnf: %v", err)
+	}
+	kernel, err := pb.LeanKernel()
+	if err != nil {
+		t.Fatalf("could not compute lean kernel: %v", err)
+	}
+	if s := solver.New(solver.ParseSliceNb(kernel.Clauses, kernel.NbVars)); s.Solve() != solver.Unsat {
+		t.Errorf("lean kernel was satisfiable")
+	}
+}
@@ -143,6 +143,9 @@ func (pb *Problem) UnsatSubset() (subset *Problem, err error) {
 			// clause was used to prove pb is UNSAT: it's part of the subset
 			subset.Clauses = append(subset.Clauses, clause)
 			subset.NbClauses++
+			if pb.Sources != nil {
+				subset.Sources = append(subset.Sources, pb.Sources[i])
+			}
 		}
 	}
 	return subset, nil
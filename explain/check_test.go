@@ -225,6 +225,80 @@ func TestMUSDeletion(t *testing.T) {
 	}
 }
 
+func TestMUSIncremental(t *testing.T) {
+	cnf, err := os.Open("testcnf/50.cnf")
+	if err != nil {
+		t.Errorf("could not read CNF file: %v", err)
+		return
+	}
+	defer cnf.Close()
+	pb, err := ParseCNF(cnf)
+	if err != nil {
+		t.Fatalf("could not parse cnf: %v", err)
+	}
+	mus, err := pb.MUSIncremental(0, 0)
+	if err != nil {
+		t.Fatalf("could not extract subset: %v", err)
+	}
+	s := solver.New(solver.ParseSlice(mus.Clauses))
+	if s.Solve() != solver.Unsat {
+		t.Errorf("mus was satisfiable")
+	}
+	for i := range mus.Clauses {
+		clauses := make([][]int, 0, len(mus.Clauses)-1)
+		clauses = append(clauses, mus.Clauses[:i]...)
+		clauses = append(clauses, mus.Clauses[i+1:]...)
+		s := solver.New(solver.ParseSliceNb(clauses, mus.NbVars))
+		if s.Solve() != solver.Sat {
+			t.Errorf("mus was not minimal: clause #%d can be removed", i+1)
+		}
+	}
+	subset, err := pb.MUSIncremental(1, 0)
+	if err != ErrBudgetExceeded {
+		t.Fatalf("expected budget to be exceeded, got error %v", err)
+	}
+	s = solver.New(solver.ParseSlice(subset.Clauses))
+	if s.Solve() != solver.Unsat {
+		t.Errorf("subset was satisfiable")
+	}
+}
+
+func TestMUSParallel(t *testing.T) {
+	cnf, err := os.Open("testcnf/50.cnf")
+	if err != nil {
+		t.Errorf("could not read CNF file: %v", err)
+		return
+	}
+	defer cnf.Close()
+	pb, err := ParseCNF(cnf)
+	if err != nil {
+		t.Fatalf("could not parse cnf: %v", err)
+	}
+	for _, nbWorkers := range []int{1, 4} {
+		mus, err := pb.MUSParallel(nbWorkers)
+		if err != nil {
+			t.Fatalf("could not extract subset with %d workers: %v", nbWorkers, err)
+		}
+		s := solver.New(solver.ParseSlice(mus.Clauses))
+		if s.Solve() != solver.Unsat {
+			t.Errorf("mus was satisfiable with %d workers", nbWorkers)
+		}
+		for i := range mus.Clauses {
+			clauses := make([][]int, 0, len(mus.Clauses)-1)
+			clauses = append(clauses, mus.Clauses[:i]...)
+			clauses = append(clauses, mus.Clauses[i+1:]...)
+			s := solver.New(solver.ParseSliceNb(clauses, mus.NbVars))
+			if s.Solve() != solver.Sat {
+				t.Errorf("mus was not minimal with %d workers: clause #%d can be removed", nbWorkers, i+1)
+			}
+		}
+	}
+	sat := &Problem{NbVars: 2, NbClauses: 2, Clauses: [][]int{{1, 2}, {-1}}}
+	if _, err := sat.MUSParallel(2); err != ErrNotUnsat {
+		t.Errorf("expected ErrNotUnsat, got %v", err)
+	}
+}
+
 func ExampleProblem_CNF() {
 	const cnf = `p cnf 3 3
 	c This is a simple problem
@@ -245,6 +319,48 @@ func ExampleProblem_CNF() {
 	// 2 0
 }
 
+func TestSources(t *testing.T) {
+	const cnf = `c generated file
+	p cnf 3 5
+	c rule: a implies b
+	-1 2 0
+	c rule: b excludes a
+	-2 -1 0
+	c user: a
+	1 0
+	c user: c
+	3 0
+	-3 2 0`
+	pb, err := ParseCNF(strings.NewReader(cnf))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	if res := fmt.Sprint(pb.Sources); res != "[line 4: rule: a implies b line 6: rule: b excludes a line 8: user: a line 10: user: c line 11: user: c]" {
+		t.Errorf("invalid sources %s", res)
+	}
+	mus, err := pb.MUS()
+	if err != nil {
+		t.Fatalf("could not compute MUS: %v", err)
+	}
+	if res := fmt.Sprint(mus.Sources); res != "[line 4: rule: a implies b line 6: rule: b excludes a line 8: user: a]" {
+		t.Errorf("invalid MUS sources %s", res)
+	}
+	subset, err := pb.UnsatSubset()
+	if err != nil {
+		t.Fatalf("could not compute unsat subset: %v", err)
+	}
+	if len(subset.Sources) != len(subset.Clauses) {
+		t.Errorf("got %d sources for %d clauses in unsat subset", len(subset.Sources), len(subset.Clauses))
+	}
+	pb, err = ParseCNFFile("testcnf/50.cnf")
+	if err != nil {
+		t.Fatalf("could not parse file: %v", err)
+	}
+	if src := pb.Sources[0]; src.File != "testcnf/50.cnf" || src.Line == 0 {
+		t.Errorf("invalid source %v", src)
+	}
+}
+
 func ExampleProblem_MUS() {
 	const cnf = `p cnf 6 9
 	c This is a simple problem
@@ -330,3 +446,35 @@ func BenchmarkMUSDeletion(b *testing.B) {
 		pb.MUSDeletion()
 	}
 }
+
+func BenchmarkMUSIncremental(b *testing.B) {
+	content, err := ioutil.ReadFile("testcnf/50.cnf")
+	if err != nil {
+		b.Errorf("could not read CNF file: %v", err)
+		return
+	}
+	for i := 0; i < b.N; i++ {
+		cnf := strings.NewReader(string(content))
+		pb, err := ParseCNF(cnf)
+		if err != nil {
+			b.Fatalf("could not parse cnf: %v", err)
+		}
+		pb.MUSIncremental(0, 0)
+	}
+}
+
+func BenchmarkMUSParallel(b *testing.B) {
+	content, err := ioutil.ReadFile("testcnf/50.cnf")
+	if err != nil {
+		b.Errorf("could not read CNF file: %v", err)
+		return
+	}
+	for i := 0; i < b.N; i++ {
+		cnf := strings.NewReader(string(content))
+		pb, err := ParseCNF(cnf)
+		if err != nil {
+			b.Fatalf("could not parse cnf: %v", err)
+		}
+		pb.MUSParallel(0)
+	}
+}
@@ -0,0 +1,228 @@
+package explain
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/crillab/gophersat/solver"
+)
+
+// AddGroup adds the given clauses to the problem, as part of the group with the given name.
+// Groups are typically used when each high-level constraint, such as a requirement, is compiled into several clauses:
+// GroupMUS will then tell which constraints conflict, rather than which clauses.
+// Clauses added to the "" group are hard: they are considered as part of all subsets.
+// Clauses that were part of the problem before the first call to AddGroup are considered hard, too.
+// NbVars is updated if the clauses contain vars that were not part of the problem yet.
+func (pb *Problem) AddGroup(name string, clauses [][]int) {
+	for len(pb.Groups) < len(pb.Clauses) {
+		pb.Groups = append(pb.Groups, "")
+	}
+	for _, clause := range clauses {
+		for _, lit := range clause {
+			v := lit
+			if v < 0 {
+				v = -v
+			}
+			if v > pb.NbVars {
+				pb.NbVars = v
+			}
+		}
+		for len(pb.units) < pb.NbVars {
+			pb.units = append(pb.units, 0)
+		}
+		if len(clause) == 1 {
+			if lit := clause[0]; lit > 0 {
+				pb.units[lit-1] = 1
+			} else {
+				pb.units[-lit-1] = -1
+			}
+		}
+		pb.Clauses = append(pb.Clauses, clause)
+		pb.Groups = append(pb.Groups, name)
+		if pb.Sources != nil {
+			pb.Sources = append(pb.Sources, Source{})
+		}
+	}
+	pb.NbClauses = len(pb.Clauses)
+}
+
+// ParseGCNF parses a problem in the group-CNF syntax, as used in the MUS track of the 2011 SAT competition,
+// and returns the associated problem.
+// The header is of the form "p gcnf <nbvars> <nbclauses> <nbgroups>", and each clause is prefixed by its group,
+// e.g "{2} 1 -3 0". Group 0 contains hard clauses. Other groups are named after their index, e.g "2".
+// As with ParseCNF, the source of each clause is recorded in pb.Sources.
+func ParseGCNF(r io.Reader) (*Problem, error) {
+	sc := bufio.NewScanner(r)
+	var pb Problem
+	nbGroups := -1
+	lineNb := 0
+	label := "" // Text of the last comment
+	for sc.Scan() {
+		line := sc.Text()
+		lineNb++
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch {
+		case fields[0] == "c":
+			label = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "c"))
+		case fields[0] == "p":
+			if len(fields) != 5 || fields[1] != "gcnf" {
+				return nil, fmt.Errorf("invalid header %q", line)
+			}
+			if err := pb.parseHeader(fields[:4]); err != nil {
+				return nil, fmt.Errorf("could not parse header %q: %v", line, err)
+			}
+			var err error
+			if nbGroups, err = strconv.Atoi(fields[4]); err != nil || nbGroups < 0 {
+				return nil, fmt.Errorf("invalid number of groups in header %q", line)
+			}
+			label = "" // Comments before the header describe the whole problem
+		case strings.HasPrefix(fields[0], "{") && strings.HasSuffix(fields[0], "}"):
+			if nbGroups == -1 {
+				return nil, fmt.Errorf("clause %q found before header", line)
+			}
+			group, err := strconv.Atoi(fields[0][1 : len(fields[0])-1])
+			if err != nil || group < 0 || group > nbGroups {
+				return nil, fmt.Errorf("invalid group in clause %q", line)
+			}
+			if err := pb.parseClause(fields[1:]); err != nil {
+				return nil, fmt.Errorf("could not parse clause %q: %v", line, err)
+			}
+			name := ""
+			if group != 0 {
+				name = strconv.Itoa(group)
+			}
+			pb.Groups = append(pb.Groups, name)
+			pb.Sources = append(pb.Sources, Source{Line: lineNb, Label: label})
+		default:
+			return nil, fmt.Errorf("clause %q has no group", line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("could not parse problem: %v", err)
+	}
+	return &pb, nil
+}
+
+// ParseGCNFFile parses the GCNF file at the given path and returns the associated problem.
+// Contrary to ParseGCNF, the sources of the clauses also record the path of the file.
+func ParseGCNFFile(path string) (*Problem, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %q: %v", path, err)
+	}
+	defer f.Close()
+	pb, err := ParseGCNF(f)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pb.Sources {
+		pb.Sources[i].File = path
+	}
+	return pb, nil
+}
+
+// GroupMUS returns a minimal unsatisfiable set of groups, i.e a set of groups such that the clauses of these groups,
+// along with the hard clauses, are unsatisfiable, and that is minimal for that property.
+// Groups are defined by pb.Groups, typically set through AddGroup or ParseGCNF.
+// If pb.Groups is nil, each clause is its own group, named after its index in pb.Clauses, starting from 1.
+// Groups are returned in the order they first appear in the problem.
+// As with MUSDeletion, the MUS is computed through the deletion method: a selector is associated with each group,
+// and all groups are removed one by one, a group being kept iff its removal makes the problem satisfiable.
+// If the problem is satisfiable, or if the hard clauses alone are unsatisfiable, the method returns ErrNotUnsat.
+func (pb *Problem) GroupMUS() (groups []string, err error) {
+	check, err := newGroupChecker(pb)
+	if err != nil {
+		return nil, err
+	}
+	groups = check.groups
+	active := make([]bool, len(groups)) // Groups that are still part of the MUS
+	for i := range active {
+		active[i] = true
+	}
+	if check.sat(active) {
+		return nil, ErrNotUnsat
+	}
+	if !check.sat(make([]bool, len(groups))) {
+		return nil, ErrNotUnsat // Hard clauses are UNSAT by themselves
+	}
+	var mus []string
+	for i := range groups {
+		// Remove current group, i.e falsify its selector
+		active[i] = false
+		if check.sat(active) {
+			// It is now sat; reinsert the group
+			active[i] = true
+			mus = append(mus, groups[i])
+			if pb.Options.Verbose {
+				fmt.Printf("c group %d/%d: kept\n", i+1, len(groups))
+			}
+		} else if pb.Options.Verbose {
+			fmt.Printf("c group %d/%d: removed\n", i+1, len(groups))
+		}
+	}
+	return mus, nil
+}
+
+// A groupChecker checks the satisfiability of subsets of the groups of a problem, along with its hard clauses.
+// A selector is associated with each group, so that a single solver can be used for all checks.
+type groupChecker struct {
+	pb     *Problem
+	s      *solver.Solver
+	groups []string       // Names of the groups, in the order they first appear in the problem
+	index  map[string]int // Index of each group in groups
+}
+
+// newGroupChecker returns a groupChecker for pb.
+// If pb.Groups is nil, each clause is its own group, named after its index in pb.Clauses, starting from 1.
+func newGroupChecker(pb *Problem) (*groupChecker, error) {
+	names := pb.Groups
+	if names == nil {
+		names = make([]string, len(pb.Clauses))
+		for i := range names {
+			names[i] = strconv.Itoa(i + 1)
+		}
+	}
+	if len(names) != len(pb.Clauses) {
+		return nil, fmt.Errorf("got %d groups for %d clauses", len(names), len(pb.Clauses))
+	}
+	check := &groupChecker{pb: pb, index: make(map[string]int)}
+	for _, name := range names {
+		if _, ok := check.index[name]; !ok && name != "" {
+			check.index[name] = len(check.groups)
+			check.groups = append(check.groups, name)
+		}
+	}
+	clauses := make([][]int, len(pb.Clauses))
+	for i, clause := range pb.Clauses {
+		if names[i] == "" {
+			clauses[i] = clause
+			continue
+		}
+		clauses[i] = make([]int, len(clause)+1)
+		copy(clauses[i], clause)
+		clauses[i][len(clause)] = -(pb.NbVars + check.index[names[i]] + 1) // Clause is active iff its selector is true
+	}
+	check.s = solver.New(solver.ParseSliceNb(clauses, pb.NbVars+len(check.groups)))
+	return check, nil
+}
+
+// sat returns true iff the hard clauses, along with the clauses of the groups i such that active[i] is true,
+// are satisfiable when the given lits are assumed.
+func (check *groupChecker) sat(active []bool, lits ...solver.Lit) bool {
+	assumptions := make([]solver.Lit, len(active), len(active)+len(lits))
+	for i, in := range active {
+		assumptions[i] = solver.IntToLit(int32(check.pb.NbVars + i + 1))
+		if !in {
+			assumptions[i] = assumptions[i].Negation()
+		}
+	}
+	assumptions = append(assumptions, lits...)
+	return check.s.Assume(assumptions) != solver.Unsat && check.s.Solve() == solver.Sat
+}
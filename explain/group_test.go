@@ -0,0 +1,65 @@
+package explain
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseGCNF(t *testing.T) {
+	const gcnf = `p gcnf 3 6 3
+	c x1 and x2 are both true, unless x3 is
+	{0} 1 3 0
+	{0} 2 3 0
+	{1} -3 0
+	{2} -1 -2 0
+	{3} -1 0
+	{3} 2 0`
+	pb, err := ParseGCNF(strings.NewReader(gcnf))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	if pb.NbClauses != 6 || fmt.Sprint(pb.Groups) != "[  1 2 3 3]" {
+		t.Fatalf("invalid problem: %d clauses, groups %q", pb.NbClauses, pb.Groups)
+	}
+	if src := pb.Sources[1]; src.Line != 4 || src.Label != "x1 and x2 are both true, unless x3 is" {
+		t.Errorf("invalid source %v", src)
+	}
+	groups, err := pb.GroupMUS()
+	if err != nil {
+		t.Fatalf("could not compute group MUS: %v", err)
+	}
+	if res := fmt.Sprint(groups); res != "[1 3]" && res != "[1 2]" {
+		t.Errorf("invalid group MUS %v", groups)
+	}
+	if _, err := ParseGCNF(strings.NewReader("p gcnf 1 1 1\n{2} 1 0\n")); err == nil {
+		t.Errorf("expected an error for invalid group")
+	}
+	if _, err := ParseGCNF(strings.NewReader("p gcnf 1 1 1\n1 0\n")); err == nil {
+		t.Errorf("expected an error for clause without group")
+	}
+}
+
+func TestAddGroup(t *testing.T) {
+	var pb Problem
+	pb.AddGroup("", [][]int{{1, 2}})
+	pb.AddGroup("no x1", [][]int{{-1}})
+	pb.AddGroup("no x2", [][]int{{-2}, {-2, 3}})
+	pb.AddGroup("x3", [][]int{{3}})
+	groups, err := pb.GroupMUS()
+	if err != nil {
+		t.Fatalf("could not compute group MUS: %v", err)
+	}
+	if res := fmt.Sprint(groups); res != "[no x1 no x2]" {
+		t.Errorf("invalid group MUS %v", groups)
+	}
+	pb.Groups = nil
+	if groups, err := pb.GroupMUS(); err != nil || fmt.Sprint(groups) != "[1 2 3]" {
+		t.Errorf("expected clauses #1, #2 and #3 as MUS, got %v (err=%v)", groups, err)
+	}
+	var sat Problem
+	sat.AddGroup("g", [][]int{{1}})
+	if _, err := sat.GroupMUS(); err != ErrNotUnsat {
+		t.Errorf("expected ErrNotUnsat, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package explain
+
+import (
+	"fmt"
+
+	"github.com/crillab/gophersat/solver"
+)
+
+// ErrNotImplied is the error returned by WhyImplied when the given lit is not implied by the problem and assumptions.
+var ErrNotImplied = fmt.Errorf("lit is not implied")
+
+// WhyImplied explains why lit, a DIMACS literal such as 3 or -3, is implied by the problem under the given assumptions,
+// i.e why lit is true in all models of the problem where all assumptions, also DIMACS literals, are true.
+// This typically tells a user why an option cannot be picked: the option is then -lit.
+// It returns a minimal set of groups and assumptions that force lit, i.e the groups and assumptions of a MUS
+// of the problem, plus the assumptions, plus the negation of lit. Groups are defined as in GroupMUS:
+// clauses in the "" group are hard and are never returned, and if pb.Groups is nil, each clause is its own group,
+// named after its index in pb.Clauses, starting from 1.
+// Groups and assumptions are returned in the order they appear in the problem and in assumptions.
+// The MUS is computed through the deletion method, on a single solver, with clause-set refinement.
+// If lit is not implied, the method returns ErrNotImplied.
+func (pb *Problem) WhyImplied(lit int, assumptions []int) (groups []string, assumed []int, err error) {
+	for _, l := range append([]int{lit}, assumptions...) {
+		if l == 0 || l > pb.NbVars || -l > pb.NbVars {
+			return nil, nil, fmt.Errorf("invalid literal %d", l)
+		}
+	}
+	check, err := newGroupChecker(pb)
+	if err != nil {
+		return nil, nil, err
+	}
+	neg := solver.IntToLit(int32(-lit))
+	nbGroups := len(check.groups)
+	// Candidates are the groups, then the assumptions
+	in := make([]bool, nbGroups+len(assumptions))
+	for i := range in {
+		in[i] = true
+	}
+	sat := func() bool {
+		lits := []solver.Lit{neg}
+		for i, a := range assumptions {
+			if in[nbGroups+i] {
+				lits = append(lits, solver.IntToLit(int32(a)))
+			}
+		}
+		return check.sat(in[:nbGroups], lits...)
+	}
+	// refine removes the candidates that were not needed to prove the last check was UNSAT.
+	refine := func() {
+		used := make(map[solver.Lit]bool)
+		for _, l := range check.s.FailedAssumptions() {
+			used[l] = true
+		}
+		for i := range in {
+			lit := solver.IntToLit(int32(pb.NbVars + i + 1))
+			if i >= nbGroups {
+				lit = solver.IntToLit(int32(assumptions[i-nbGroups]))
+			}
+			in[i] = in[i] && used[lit]
+		}
+	}
+	if sat() {
+		return nil, nil, ErrNotImplied
+	}
+	refine()
+	for i := range in {
+		if !in[i] {
+			continue
+		}
+		in[i] = false
+		if sat() {
+			in[i] = true
+		} else {
+			refine()
+		}
+	}
+	for i, g := range check.groups {
+		if in[i] {
+			groups = append(groups, g)
+		}
+	}
+	for i, a := range assumptions {
+		if in[nbGroups+i] {
+			assumed = append(assumed, a)
+		}
+	}
+	return groups, assumed, nil
+}
@@ -0,0 +1,31 @@
+package explain
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestWhyImplied(t *testing.T) {
+	// Vars: 1 = sunroof, 2 = roof rack, 3 = sport pack, 4 = metallic paint
+	var pb Problem
+	pb.AddGroup("no roof rack with sunroof", [][]int{{-1, -2}})
+	pb.AddGroup("sport pack includes roof rack", [][]int{{-3, 2}})
+	pb.AddGroup("metallic paint with sport pack", [][]int{{-3, 4}})
+	groups, assumed, err := pb.WhyImplied(-1, []int{4, 3})
+	if err != nil {
+		t.Fatalf("could not explain implied literal: %v", err)
+	}
+	if fmt.Sprint(groups) != "[no roof rack with sunroof sport pack includes roof rack]" || fmt.Sprint(assumed) != "[3]" {
+		t.Errorf("invalid explanation: groups %q, assumptions %v", groups, assumed)
+	}
+	if _, _, err := pb.WhyImplied(-1, []int{4}); err != ErrNotImplied {
+		t.Errorf("expected ErrNotImplied, got %v", err)
+	}
+	if _, _, err := pb.WhyImplied(5, nil); err == nil {
+		t.Errorf("expected an error for invalid literal")
+	}
+	pb.Groups = nil
+	if groups, assumed, err := pb.WhyImplied(4, []int{3}); err != nil || fmt.Sprint(groups) != "[3]" || fmt.Sprint(assumed) != "[3]" {
+		t.Errorf("expected clause #3 and assumption 3, got %v and %v (err=%v)", groups, assumed, err)
+	}
+}
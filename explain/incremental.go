@@ -0,0 +1,203 @@
+package explain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/crillab/gophersat/solver"
+)
+
+// ErrBudgetExceeded is the error returned by MUSIncremental when it ran out of time or conflicts.
+// The returned subset is then unsatisfiable, but not necessarily minimal.
+var ErrBudgetExceeded = fmt.Errorf("budget exceeded")
+
+// MUSIncremental returns a Minimal Unsatisfiable Subset for the problem using the deletion method,
+// on a single incremental solver.
+// A MUS is an unsatisfiable subset such that, if any of its clause is removed,
+// the problem becomes satisfiable.
+// Contrary to MUSDeletion, a selector lit is associated with each clause, so that the same solver,
+// and the clauses it learned, are used for all SAT calls. Besides, two techniques reduce the number of calls:
+//   - clause-set refinement: when removing a clause makes the problem UNSAT, all clauses that were not used
+//     to prove it, as given by the solver's failed assumptions, are removed too;
+//   - model rotation: when removing a clause makes the problem SAT, the clause is part of the MUS, and the model
+//     is locally modified to find other clauses that are the only ones falsified by the modified model,
+//     which are part of the MUS too.
+//
+// maxConflicts and timeout limit the total work done by the solver. If any of them is exceeded,
+// the best subset found so far is returned, i.e an unsatisfiable subset that might not be minimal,
+// along with ErrBudgetExceeded. A value of 0 means there is no limit.
+// If the problem is satisfiable, the method returns ErrNotUnsat.
+func (pb *Problem) MUSIncremental(maxConflicts int, timeout time.Duration) (mus *Problem, err error) {
+	ext := newMUSExtractor(pb, maxConflicts, timeout)
+	switch ext.check(-1) {
+	case solver.Sat:
+		return nil, ErrNotUnsat
+	case solver.Indet:
+		return ext.subset(), ErrBudgetExceeded
+	}
+	ext.refine()
+	for i := range pb.Clauses {
+		if !ext.in[i] || ext.necessary[i] {
+			continue
+		}
+		switch ext.check(i) {
+		case solver.Sat:
+			ext.necessary[i] = true
+			nb := ext.rotate(i)
+			if pb.Options.Verbose {
+				fmt.Printf("c clause %d/%d: kept, %d more clauses found through model rotation\n", i+1, len(pb.Clauses), nb)
+			}
+		case solver.Unsat:
+			ext.refine()
+			if pb.Options.Verbose {
+				fmt.Printf("c clause %d/%d: removed, %d clauses left\n", i+1, len(pb.Clauses), ext.nbIn())
+			}
+		default:
+			return ext.subset(), ErrBudgetExceeded
+		}
+	}
+	return ext.subset(), nil
+}
+
+// A musExtractor holds the state of an incremental MUS extraction.
+type musExtractor struct {
+	pb           *Problem
+	s            *solver.Solver
+	in           []bool  // Clauses that are still part of the candidate subset
+	necessary    []bool  // Clauses that are known to be part of the MUS
+	occurs       [][]int // For each lit, the clauses it appears in; index is given by solver.Lit
+	maxConflicts int     // Total number of conflicts allowed, or 0 if there is no limit
+	deadline     time.Time
+}
+
+// newMUSExtractor returns a musExtractor for pb, where all clauses are part of the candidate subset.
+func newMUSExtractor(pb *Problem, maxConflicts int, timeout time.Duration) *musExtractor {
+	nbClauses := len(pb.Clauses)
+	ext := &musExtractor{
+		pb:           pb,
+		in:           make([]bool, nbClauses),
+		necessary:    make([]bool, nbClauses),
+		occurs:       make([][]int, 2*pb.NbVars),
+		maxConflicts: maxConflicts,
+	}
+	if timeout > 0 {
+		ext.deadline = time.Now().Add(timeout)
+	}
+	clauses := make([][]int, nbClauses)
+	for i, clause := range pb.Clauses {
+		ext.in[i] = true
+		clauses[i] = make([]int, len(clause)+1)
+		copy(clauses[i], clause)
+		clauses[i][len(clause)] = -(pb.NbVars + i + 1) // Clause is active iff its selector is true
+		for _, lit := range clause {
+			l := solver.IntToLit(int32(lit))
+			ext.occurs[l] = append(ext.occurs[l], i)
+		}
+	}
+	ext.s = solver.New(solver.ParseSliceNb(clauses, pb.NbVars+nbClauses))
+	return ext
+}
+
+// check returns the status of the candidate subset, deprived from clause i if i is not -1.
+// It returns Indet if the budget was exceeded.
+func (ext *musExtractor) check(i int) solver.Status {
+	nbConflicts := ext.s.Stats.NbConflicts
+	if ext.maxConflicts > 0 && nbConflicts >= ext.maxConflicts {
+		return solver.Indet
+	}
+	ext.s.SetBudget(ext.maxConflicts-nbConflicts, ext.deadline)
+	assumptions := make([]solver.Lit, len(ext.in))
+	for j, in := range ext.in {
+		assumptions[j] = solver.IntToLit(int32(ext.pb.NbVars + j + 1))
+		if !in || j == i {
+			assumptions[j] = assumptions[j].Negation()
+		}
+	}
+	if status := ext.s.Assume(assumptions); status == solver.Unsat {
+		return status
+	}
+	return ext.s.Solve()
+}
+
+// refine removes from the candidate subset all the clauses that were not needed
+// to prove the last check was UNSAT.
+func (ext *musExtractor) refine() {
+	used := make([]bool, len(ext.in))
+	for _, lit := range ext.s.FailedAssumptions() {
+		if lit.IsPositive() {
+			used[int(lit.Var())-ext.pb.NbVars] = true
+		}
+	}
+	for i := range ext.in {
+		ext.in[i] = ext.in[i] && used[i]
+	}
+}
+
+// rotate applies recursive model rotation from the last model, which falsifies clause i
+// and satisfies all other clauses from the candidate subset.
+// Each var of the falsified clause is flipped in turn: if the modified model falsifies a single clause
+// from the subset, that clause is necessary, too, and the rotation goes on from it.
+// It returns the number of new necessary clauses.
+func (ext *musExtractor) rotate(i int) int {
+	model := ext.s.Model()[:ext.pb.NbVars]
+	nb := 0
+	type step struct {
+		clause int
+		model  []bool
+	}
+	steps := []step{{i, model}}
+	for len(steps) > 0 {
+		cur := steps[len(steps)-1]
+		steps = steps[:len(steps)-1]
+		for _, lit := range ext.pb.Clauses[cur.clause] {
+			// After flipping, the opposite lit becomes false: only clauses containing it might become falsified
+			v := lit
+			if v < 0 {
+				v = -v
+			}
+			falsified := -1
+			opposite := solver.IntToLit(int32(-lit))
+			cur.model[v-1] = !cur.model[v-1]
+			for _, j := range ext.occurs[opposite] {
+				if ext.in[j] && !satClause(ext.pb.Clauses[j], cur.model) {
+					if falsified != -1 {
+						falsified = -1
+						break
+					}
+					falsified = j
+				}
+			}
+			if falsified != -1 && !ext.necessary[falsified] {
+				ext.necessary[falsified] = true
+				nb++
+				model2 := make([]bool, len(cur.model))
+				copy(model2, cur.model)
+				steps = append(steps, step{falsified, model2})
+			}
+			cur.model[v-1] = !cur.model[v-1]
+		}
+	}
+	return nb
+}
+
+// nbIn returns the number of clauses in the candidate subset.
+func (ext *musExtractor) nbIn() int {
+	nb := 0
+	for _, in := range ext.in {
+		if in {
+			nb++
+		}
+	}
+	return nb
+}
+
+// subset returns the candidate subset.
+func (ext *musExtractor) subset() *Problem {
+	var indices []int
+	for i, in := range ext.in {
+		if in {
+			indices = append(indices, i)
+		}
+	}
+	return ext.pb.subset(indices)
+}
@@ -0,0 +1,166 @@
+package explain
+
+import (
+	"fmt"
+
+	"github.com/crillab/gophersat/solver"
+)
+
+// EnumerateMUSes enumerates all the Minimal Unsatisfiable Subsets and all the Minimal Correction Sets of the problem.
+// A MUS is an unsatisfiable subset such that, if any of its clause is removed, the problem becomes satisfiable.
+// A MCS is a subset of clauses such that, if all its clauses are removed, the problem becomes satisfiable,
+// and that is minimal for that property. Each MCS contains at least one clause from each MUS, and conversely.
+// Each MUS is written on muses and each MCS is written on mcses as soon as it is found, and both channels
+// are closed at the end of the method. Any of them can be nil, in which case the corresponding subsets are
+// computed but not returned.
+// If stop is non-nil, enumeration stops as soon as a value is received or stop is closed.
+// Subsets are enumerated in the MARCO style: a map solver, whose vars tell which clauses belong to a subset,
+// generates candidate subsets that are neither a superset of a known MUS nor a subset of the complement of a known MCS.
+// Each candidate is then either shrunk to a new MUS, or grown to the complement of a new MCS.
+// Since the number of MUSes can be exponential in the number of clauses, enumeration can take a very long time.
+// If the problem is satisfiable, it has no MUS and the method returns ErrNotUnsat.
+func (pb *Problem) EnumerateMUSes(muses, mcses chan *Problem, stop chan struct{}) error {
+	if muses != nil {
+		defer close(muses)
+	}
+	if mcses != nil {
+		defer close(mcses)
+	}
+	nbClauses := len(pb.Clauses)
+	check := newSubsetChecker(pb)
+	all := make([]bool, nbClauses)
+	for i := range all {
+		all[i] = true
+	}
+	if check.sat(all) {
+		return ErrNotUnsat
+	}
+	// The map solver has one var for each clause. Its models are the subsets that are still to be explored.
+	mapSolver := solver.New(solver.ParseSliceNb(nil, nbClauses))
+	mapSolver.SetHint(all) // Prefer big subsets, which are more likely to be unsatisfiable
+	nbMUS, nbMCS := 0, 0
+	for {
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		if mapSolver.Solve() != solver.Sat {
+			return nil
+		}
+		seed := mapSolver.Model()
+		if check.sat(seed) {
+			mss := check.grow(seed)
+			var lits []solver.Lit // At least one of the clauses of the MCS must be part of the next subsets
+			var clauses []int     // Indices of the clauses
+			for i, in := range mss {
+				if !in {
+					lits = append(lits, solver.IntToLit(int32(i+1)))
+					clauses = append(clauses, i)
+				}
+			}
+			nbMCS++
+			if pb.Options.Verbose {
+				fmt.Printf("c found MCS #%d with %d clauses\n", nbMCS, len(clauses))
+			}
+			if mcses != nil {
+				mcses <- pb.subset(clauses)
+			}
+			mapSolver.AppendClause(solver.NewClause(lits))
+		} else {
+			mus := check.shrink(seed)
+			var lits []solver.Lit // At least one of the clauses of the MUS must not be part of the next subsets
+			var clauses []int     // Indices of the clauses
+			for i, in := range mus {
+				if in {
+					lits = append(lits, solver.IntToLit(int32(-i-1)))
+					clauses = append(clauses, i)
+				}
+			}
+			nbMUS++
+			if pb.Options.Verbose {
+				fmt.Printf("c found MUS #%d with %d clauses\n", nbMUS, len(clauses))
+			}
+			if muses != nil {
+				muses <- pb.subset(clauses)
+			}
+			mapSolver.AppendClause(solver.NewClause(lits))
+		}
+	}
+}
+
+// A subsetChecker checks the satisfiability of subsets of the clauses of a problem.
+// A relax var is associated with each clause, so that a single solver can be used for all checks.
+type subsetChecker struct {
+	pb *Problem
+	s  *solver.Solver
+}
+
+// newSubsetChecker returns a subsetChecker for pb.
+func newSubsetChecker(pb *Problem) *subsetChecker {
+	clauses := make([][]int, len(pb.Clauses))
+	for i, clause := range pb.Clauses {
+		clauses[i] = make([]int, len(clause)+1)
+		copy(clauses[i], clause)
+		clauses[i][len(clause)] = -(pb.NbVars + i + 1) // Clause is active iff its relax var is true
+	}
+	return &subsetChecker{pb: pb, s: solver.New(solver.ParseSliceNb(clauses, pb.NbVars+len(pb.Clauses)))}
+}
+
+// sat returns true iff the subset made of the clauses i such that subset[i] is true is satisfiable.
+func (c *subsetChecker) sat(subset []bool) bool {
+	assumptions := make([]solver.Lit, len(subset))
+	for i, in := range subset {
+		assumptions[i] = solver.IntToLit(int32(c.pb.NbVars + i + 1))
+		if !in {
+			assumptions[i] = assumptions[i].Negation()
+		}
+	}
+	return c.s.Assume(assumptions) != solver.Unsat && c.s.Solve() == solver.Sat
+}
+
+// grow returns a Maximal Satisfiable Subset that contains the given satisfiable subset.
+// The checker's last call to sat must have been on that subset.
+func (c *subsetChecker) grow(subset []bool) []bool {
+	mss := make([]bool, len(subset))
+	copy(mss, subset)
+	c.addSatisfied(mss)
+	for i := range mss {
+		if mss[i] {
+			continue
+		}
+		mss[i] = true
+		if c.sat(mss) {
+			c.addSatisfied(mss)
+		} else {
+			mss[i] = false
+		}
+	}
+	return mss
+}
+
+// addSatisfied adds to subset all the clauses satisfied by the checker's last model.
+func (c *subsetChecker) addSatisfied(subset []bool) {
+	model := c.s.Model()
+	for i, clause := range c.pb.Clauses {
+		if !subset[i] && satClause(clause, model) {
+			subset[i] = true
+		}
+	}
+}
+
+// shrink returns a MUS that is included in the given unsatisfiable subset, using the deletion method.
+func (c *subsetChecker) shrink(subset []bool) []bool {
+	mus := make([]bool, len(subset))
+	copy(mus, subset)
+	for i := range mus {
+		if !mus[i] {
+			continue
+		}
+		mus[i] = false
+		if c.sat(mus) {
+			mus[i] = true
+		}
+	}
+	return mus
+}
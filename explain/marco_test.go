@@ -0,0 +1,73 @@
+package explain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// subsets returns a canonical representation of the given subsets, for testing purposes.
+func subsets(pbs []*Problem) string {
+	res := make([]string, len(pbs))
+	for i, pb := range pbs {
+		clauses := make([]string, len(pb.Clauses))
+		for j, clause := range pb.Clauses {
+			clauses[j] = fmt.Sprint(clause)
+		}
+		sort.Strings(clauses)
+		res[i] = strings.Join(clauses, " ")
+	}
+	sort.Strings(res)
+	return strings.Join(res, "\n")
+}
+
+func TestEnumerateMUSes(t *testing.T) {
+	const cnf = `p cnf 2 5
+	1 0
+	-1 0
+	2 0
+	-2 0
+	1 2 0`
+	pb, err := ParseCNF(strings.NewReader(cnf))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	muses := make(chan *Problem)
+	mcses := make(chan *Problem)
+	var allMUSes, allMCSes []*Problem
+	done := make(chan struct{})
+	go func() {
+		for mcs := range mcses {
+			allMCSes = append(allMCSes, mcs)
+		}
+		close(done)
+	}()
+	go func() {
+		if err := pb.EnumerateMUSes(muses, mcses, nil); err != nil {
+			t.Errorf("could not enumerate MUSes: %v", err)
+		}
+	}()
+	for mus := range muses {
+		allMUSes = append(allMUSes, mus)
+	}
+	<-done
+	const expectedMUSes = "[-1] [-2] [1 2]\n[-1] [1]\n[-2] [2]"
+	if res := subsets(allMUSes); res != expectedMUSes {
+		t.Errorf("invalid MUSes: expected\n%s\ngot\n%s", expectedMUSes, res)
+	}
+	const expectedMCSes = "[-1] [-2]\n[-1] [2]\n[-2] [1]\n[1 2] [1] [2]"
+	if res := subsets(allMCSes); res != expectedMCSes {
+		t.Errorf("invalid MCSes: expected\n%s\ngot\n%s", expectedMCSes, res)
+	}
+}
+
+func TestEnumerateMUSesSat(t *testing.T) {
+	pb, err := ParseCNF(strings.NewReader("p cnf 2 2\n1 2 0\n-1 0\n"))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	if err := pb.EnumerateMUSes(nil, nil, nil); err != ErrNotUnsat {
+		t.Errorf("expected ErrNotUnsat, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package explain
+
+import (
+	"fmt"
+
+	"github.com/crillab/gophersat/solver"
+)
+
+// MCS returns a Minimal Correction Set for the problem, i.e a subset of clauses such that,
+// if all its clauses are removed, the problem becomes satisfiable, and that is minimal for that property.
+// While a MUS explains why a problem is UNSAT, a MCS tells what should be removed to make it SAT.
+// weights associates a strictly positive weight with each clause of the problem, i.e the cost of removing it.
+// The returned MCS is one of the cheapest ones, i.e the sum of the weights of its clauses is minimal.
+// If weights is nil, all clauses have a weight of 1, so the returned MCS is one of the smallest ones.
+// As with MUSMaxSat, the MCS is computed through a call to MaxSat, a relax lit being added to each clause.
+// If the problem is satisfiable, the method returns ErrNotUnsat.
+func (pb *Problem) MCS(weights []int) (mcs *Problem, err error) {
+	finder, err := newMCSFinder(pb, weights)
+	if err != nil {
+		return nil, err
+	}
+	clauses := finder.next()
+	if len(clauses) == 0 {
+		return nil, ErrNotUnsat
+	}
+	return finder.subset(clauses), nil
+}
+
+// AllMCS enumerates all the Minimal Correction Sets of the problem, as defined by MCS, and writes them on mcses.
+// MCSes are enumerated by increasing cost: weights associates a strictly positive weight with each clause of the problem,
+// and the cost of a MCS is the sum of the weights of its clauses. If weights is nil, all clauses have a weight of 1.
+// mcses is closed at the end of the method. It can be nil, in which case MCSes are computed but not returned.
+// If stop is non-nil, enumeration stops as soon as a value is received or stop is closed.
+// Once a MCS is found, it is blocked, so that next calls to MaxSat only return sets that are not a superset of it.
+// If the problem is satisfiable, the method returns ErrNotUnsat.
+func (pb *Problem) AllMCS(weights []int, mcses chan *Problem, stop chan struct{}) error {
+	if mcses != nil {
+		defer close(mcses)
+	}
+	finder, err := newMCSFinder(pb, weights)
+	if err != nil {
+		return err
+	}
+	for nb := 0; ; nb++ {
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		clauses := finder.next()
+		if clauses == nil {
+			return nil
+		}
+		if len(clauses) == 0 {
+			return ErrNotUnsat
+		}
+		if pb.Options.Verbose {
+			fmt.Printf("c found MCS #%d with %d clauses\n", nb+1, len(clauses))
+		}
+		if mcses != nil {
+			mcses <- finder.subset(clauses)
+		}
+	}
+}
+
+// A mcsFinder finds the cheapest MCSes of a problem, through calls to MaxSat.
+// All calls are made on the same solver, blocking clauses being added as MCSes are found.
+type mcsFinder struct {
+	pb *Problem
+	s  *solver.Solver // Solver for the relaxed clauses, whose cost function is the cost of removed clauses
+}
+
+// newMCSFinder returns a mcsFinder for pb with the given weights.
+// It returns an error if weights are invalid.
+func newMCSFinder(pb *Problem, weights []int) (*mcsFinder, error) {
+	nbClauses := len(pb.Clauses)
+	if weights != nil && len(weights) != nbClauses {
+		return nil, fmt.Errorf("got %d weights for %d clauses", len(weights), nbClauses)
+	}
+	clauses := make([][]int, nbClauses)        // Relaxed clauses
+	relaxLits := make([]solver.Lit, nbClauses) // For each clause, the lit that is true if the clause is removed
+	costs := make([]int, nbClauses)            // For each clause, the cost of removing it
+	for i, clause := range pb.Clauses {
+		relaxLit := pb.NbVars + i + 1
+		clauses[i] = make([]int, len(clause)+1)
+		copy(clauses[i], clause)
+		clauses[i][len(clause)] = relaxLit
+		relaxLits[i] = solver.IntToLit(int32(relaxLit))
+		costs[i] = 1
+		if weights != nil {
+			if weights[i] <= 0 {
+				return nil, fmt.Errorf("invalid weight %d for clause #%d", weights[i], i+1)
+			}
+			costs[i] = weights[i]
+		}
+	}
+	prob := solver.ParseSliceNb(clauses, pb.NbVars+nbClauses)
+	prob.SetCostFunc(relaxLits, costs)
+	s := solver.New(prob)
+	s.Verbose = pb.Options.Verbose
+	return &mcsFinder{pb: pb, s: s}, nil
+}
+
+// next returns the indices of the clauses of the cheapest MCS that was not found yet, and blocks it.
+// It returns nil if there are no more MCSes, and an empty, non-nil, slice if the problem is satisfiable.
+func (f *mcsFinder) next() []int {
+	// TopK only bounds the cost during the call, so that next MCSes can be more expensive.
+	res := f.s.TopK(1, nil)
+	if len(res) == 0 {
+		return nil
+	}
+	indices := []int{}
+	var block []solver.Lit // At least one clause of the MCS must be kept in next MCSes
+	for i, clause := range f.pb.Clauses {
+		if !satClause(clause, res[0].Model) {
+			indices = append(indices, i)
+			block = append(block, solver.IntToLit(int32(-(f.pb.NbVars + i + 1))))
+		}
+	}
+	if len(indices) > 0 {
+		f.s.AppendClause(solver.NewClause(block))
+	}
+	return indices
+}
+
+// subset returns the subproblem made of the clauses with the given indices.
+func (f *mcsFinder) subset(indices []int) *Problem {
+	return f.pb.subset(indices)
+}
@@ -0,0 +1,63 @@
+package explain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMCS(t *testing.T) {
+	const cnf = `p cnf 2 5
+	1 0
+	-1 0
+	2 0
+	-2 0
+	1 2 0`
+	pb, err := ParseCNF(strings.NewReader(cnf))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	mcs, err := pb.MCS(nil)
+	if err != nil {
+		t.Fatalf("could not compute MCS: %v", err)
+	}
+	if mcs.NbClauses != 2 {
+		t.Errorf("expected a MCS with 2 clauses, got %v", mcs.Clauses)
+	}
+	mcs, err = pb.MCS([]int{5, 1, 5, 1, 1})
+	if err != nil {
+		t.Fatalf("could not compute weighted MCS: %v", err)
+	}
+	if res := subsets([]*Problem{mcs}); res != "[-1] [-2]" {
+		t.Errorf("expected MCS [-1] [-2], got %s", res)
+	}
+	mcses := make(chan *Problem)
+	go func() {
+		if err := pb.AllMCS([]int{1, 1, 1, 1, 3}, mcses, nil); err != nil {
+			t.Errorf("could not enumerate MCSes: %v", err)
+		}
+	}()
+	var all []*Problem
+	for mcs := range mcses {
+		all = append(all, mcs)
+	}
+	if len(all) != 4 || all[3].NbClauses != 3 {
+		t.Errorf("expected 4 MCSes, the last one being the most expensive, got %s", subsets(all))
+	}
+	const expected = "[-1] [-2]\n[-1] [2]\n[-2] [1]\n[1 2] [1] [2]"
+	if res := subsets(all); res != expected {
+		t.Errorf("invalid MCSes: expected\n%s\ngot\n%s", expected, res)
+	}
+	if err := pb.AllMCS(nil, nil, nil); err != nil { // MCSes are computed but not returned
+		t.Errorf("could not enumerate MCSes without a channel: %v", err)
+	}
+	if _, err := pb.MCS([]int{1, 2}); err == nil {
+		t.Errorf("expected an error for invalid weights")
+	}
+	sat, err := ParseCNF(strings.NewReader("p cnf 2 2\n1 2 0\n-1 0\n"))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	if _, err := sat.MCS(nil); err != ErrNotUnsat {
+		t.Errorf("expected ErrNotUnsat, got %v", err)
+	}
+}
@@ -29,12 +29,12 @@ func (pb *Problem) MUSMaxSat() (mus *Problem, err error) {
 	prob.SetCostFunc(relaxLits, weights)
 	s := solver.New(prob)
 	s.Verbose = pb.Options.Verbose
-	var musClauses [][]int
+	var musClauses []int            // Indices of the clauses of the MUS
 	done := make([]bool, NbClauses) // Indicates whether a clause is already part of MUS or not yet
 	for {
 		cost := s.Minimize()
 		if cost == -1 {
-			return makeMus(nbVars, musClauses), nil
+			return pb.subset(musClauses), nil
 		}
 		if cost == 0 {
 			return nil, fmt.Errorf("cannot extract MUS from satisfiable problem")
@@ -45,7 +45,7 @@ func (pb *Problem) MUSMaxSat() (mus *Problem, err error) {
 				// The clause is part of the MUS
 				pb2.Clauses = append(pb2.Clauses, []int{-(nbVars + i + 1)}) // Now, relax lit has to be false
 				pb2.NbClauses++
-				musClauses = append(musClauses, clause)
+				musClauses = append(musClauses, i)
 				done[i] = true
 				// Make it a hard clause before restarting solver
 				lits := make([]solver.Lit, len(clause))
@@ -137,6 +137,9 @@ func (pb *Problem) MUSInsertion() (mus *Problem, err error) {
 		idx--                                           // We went one step too far, go back
 		mus.Clauses = append(mus.Clauses, clauses[idx]) // Last clause is part of the MUS
 		mus.NbClauses++
+		if pb2.Sources != nil {
+			mus.Sources = append(mus.Sources, pb2.Sources[idx])
+		}
 		if pb.Options.Verbose {
 			fmt.Printf("c removing %d/%d clause(s)\n", len(clauses)-idx, len(clauses))
 		}
@@ -195,6 +198,9 @@ func (pb *Problem) MUSDeletion() (mus *Problem, err error) {
 			clause := pb2.Clauses[i]
 			clause = clause[:len(clause)-1] // Remove relax lit
 			mus.Clauses = append(mus.Clauses, clause)
+			if pb2.Sources != nil {
+				mus.Sources = append(mus.Sources, pb2.Sources[i])
+			}
 		}
 		mus.NbClauses = len(mus.Clauses)
 	}
@@ -209,5 +215,5 @@ func (pb *Problem) MUSDeletion() (mus *Problem, err error) {
 // The exact algorithm used to compute the MUS is not guaranteed. If you want to use a given algorithm,
 // use the relevant functions.
 func (pb *Problem) MUS() (mus *Problem, err error) {
-	return pb.MUSDeletion()
+	return pb.MUSIncremental(0, 0)
 }
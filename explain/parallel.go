@@ -0,0 +1,111 @@
+package explain
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/crillab/gophersat/solver"
+)
+
+// MUSParallel returns a Minimal Unsatisfiable Subset for the problem using the deletion method,
+// on nbWorkers incremental solvers running concurrently. If nbWorkers is 0 or less, one worker is used per CPU.
+// The clauses are split among the workers, each of them trying to remove its own clauses from the shared
+// candidate subset, as MUSIncremental does, with clause-set refinement and model rotation.
+// Findings are merged in the shared candidate subset:
+//   - a clause that is necessary for a subset is necessary for all its subsets, so transition clauses
+//     found by a worker are always kept, even if other workers removed clauses in the meantime;
+//   - a worker that proved its candidate subset, deprived from a clause, is UNSAT, only replaces the shared subset
+//     with the core it found if all the clauses of that core are still part of the shared subset.
+//     Else, another worker removed some of them in the meantime and the clause is checked again.
+//
+// If the problem is satisfiable, the method returns ErrNotUnsat.
+func (pb *Problem) MUSParallel(nbWorkers int) (mus *Problem, err error) {
+	if nbWorkers <= 0 {
+		nbWorkers = runtime.NumCPU()
+	}
+	shared := newMUSExtractor(pb, 0, 0)
+	if shared.check(-1) == solver.Sat {
+		return nil, ErrNotUnsat
+	}
+	shared.refine()
+	var (
+		mtx sync.Mutex
+		wg  sync.WaitGroup
+	)
+	nbClauses := len(pb.Clauses)
+	for w := 0; w < nbWorkers; w++ {
+		first, last := w*nbClauses/nbWorkers, (w+1)*nbClauses/nbWorkers
+		if first == last {
+			continue
+		}
+		var ext *musExtractor
+		if w == 0 { // Reuse the solver that was already created
+			ext = &musExtractor{
+				pb:        pb,
+				s:         shared.s,
+				in:        make([]bool, nbClauses),
+				necessary: make([]bool, nbClauses),
+				occurs:    shared.occurs,
+			}
+		} else {
+			ext = newMUSExtractor(pb, 0, 0)
+		}
+		wg.Add(1)
+		go func(w, first, last int, ext *musExtractor) {
+			defer wg.Done()
+			for i := first; i < last; i++ {
+				for !shared.tryRemove(ext, i, &mtx) {
+					if pb.Options.Verbose {
+						fmt.Printf("c worker %d: clause %d/%d: removed concurrently from core, checking again\n", w, i+1, nbClauses)
+					}
+				}
+			}
+		}(w, first, last, ext)
+	}
+	wg.Wait()
+	return shared.subset(), nil
+}
+
+// tryRemove tries to remove clause i from the shared candidate subset, on the worker's extractor ext.
+// mtx protects the shared candidate subset.
+// It returns false if the check must be done again, because the shared subset was modified concurrently.
+func (shared *musExtractor) tryRemove(ext *musExtractor, i int, mtx *sync.Mutex) bool {
+	mtx.Lock()
+	if !shared.in[i] || shared.necessary[i] {
+		mtx.Unlock()
+		return true
+	}
+	copy(ext.in, shared.in)
+	copy(ext.necessary, shared.necessary)
+	mtx.Unlock()
+	nb := 0
+	status := ext.check(i)
+	if status == solver.Sat {
+		ext.necessary[i] = true
+		nb = ext.rotate(i)
+	} else {
+		ext.refine()
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	if status == solver.Sat {
+		for j, necessary := range ext.necessary {
+			shared.necessary[j] = shared.necessary[j] || necessary
+		}
+		if shared.pb.Options.Verbose {
+			fmt.Printf("c clause %d/%d: kept, %d more clauses found through model rotation\n", i+1, len(shared.in), nb)
+		}
+		return true
+	}
+	for j, in := range ext.in {
+		if in && !shared.in[j] {
+			return false
+		}
+	}
+	copy(shared.in, ext.in)
+	if shared.pb.Options.Verbose {
+		fmt.Printf("c clause %d/%d: removed, %d clauses left\n", i+1, len(shared.in), shared.nbIn())
+	}
+	return true
+}
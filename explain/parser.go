@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"os"
 	"strconv"
 	"strings"
 )
@@ -24,26 +25,36 @@ func parseClause(fields []string) ([]int, error) {
 }
 
 // ParseCNF parses a CNF and returns the associated problem.
+// The source of each clause is recorded in pb.Sources: its line number and, as a label,
+// the text of the last comment line that preceded it after the header, if any.
+// A label thus applies to all the clauses that follow it, until the next comment line.
+// This is typically used by tools generating CNFs, that annotate the clauses of each high-level constraint
+// with a comment line telling where the constraint comes from.
 func ParseCNF(r io.Reader) (*Problem, error) {
 	sc := bufio.NewScanner(r)
 	var pb Problem
+	lineNb := 0
+	label := "" // Text of the last comment
 	for sc.Scan() {
 		line := sc.Text()
+		lineNb++
 		fields := strings.Fields(line)
 		if len(fields) == 0 {
 			continue
 		}
 		switch fields[0] {
 		case "c":
-			continue
+			label = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "c"))
 		case "p":
 			if err := pb.parseHeader(fields); err != nil {
 				return nil, fmt.Errorf("could not parse header %q: %v", line, err)
 			}
+			label = "" // Comments before the header describe the whole problem
 		default:
 			if err := pb.parseClause(fields); err != nil {
 				return nil, fmt.Errorf("could not parse clause %q: %v", line, err)
 			}
+			pb.Sources = append(pb.Sources, Source{Line: lineNb, Label: label})
 		}
 	}
 	if err := sc.Err(); err != nil {
@@ -52,6 +63,24 @@ func ParseCNF(r io.Reader) (*Problem, error) {
 	return &pb, nil
 }
 
+// ParseCNFFile parses the CNF file at the given path and returns the associated problem.
+// Contrary to ParseCNF, the sources of the clauses also record the path of the file.
+func ParseCNFFile(path string) (*Problem, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %q: %v", path, err)
+	}
+	defer f.Close()
+	pb, err := ParseCNF(f)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pb.Sources {
+		pb.Sources[i].File = path
+	}
+	return pb, nil
+}
+
 func (pb *Problem) parseHeader(fields []string) error {
 	if len(fields) != 4 {
 		return fmt.Errorf("expected 4 fields, got %d", len(fields))
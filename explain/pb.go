@@ -0,0 +1,117 @@
+package explain
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/crillab/gophersat/solver"
+)
+
+// A PBProblem is a conjunction of pseudo-boolean constraints, including cardinality constraints and clauses.
+// Contrary to Problem, constraints use solver's representation.
+type PBProblem struct {
+	Constrs []solver.PBConstr
+	NbVars  int
+	Options Options
+}
+
+// ParseOPB parses a problem in the OPB syntax and returns the associated problem.
+// Each line with a "=" operator is translated into two constraints, and the cost function, if any, is ignored.
+func ParseOPB(r io.Reader) (*PBProblem, error) {
+	constrs, nbVars, err := solver.ParseOPBConstrs(r)
+	if err != nil {
+		return nil, err
+	}
+	return &PBProblem{Constrs: constrs, NbVars: nbVars}, nil
+}
+
+// MUS returns a Minimal Unsatisfiable Subset for the problem, i.e an unsatisfiable subset of its constraints such that,
+// if any of its constraints is removed, the problem becomes satisfiable.
+// As with MUSDeletion, the MUS is computed through the deletion method: a selector lit s is associated with
+// each constraint, so that a constraint "sum(w_i*l_i) >= k" becomes "sum(w_i*l_i) + k*~s >= k",
+// and constraints are removed one by one, a constraint being kept iff its removal makes the problem satisfiable.
+// If the problem is satisfiable, the method returns ErrNotUnsat.
+func (pb *PBProblem) MUS() (mus *PBProblem, err error) {
+	nbVars := pb.NbVars
+	for _, constr := range pb.Constrs {
+		for _, lit := range constr.Lits {
+			if lit > nbVars {
+				nbVars = lit
+			} else if -lit > nbVars {
+				nbVars = -lit
+			}
+		}
+	}
+	relaxed := make([]solver.PBConstr, 0, len(pb.Constrs))
+	var assumptions []solver.Lit // Selectors of the constraints that are still part of the MUS
+	var indices []int            // Index of the constraint associated with each selector
+	for i, constr := range pb.Constrs {
+		if constr.AtLeast <= 0 { // Trivially satisfied, cannot be part of the MUS
+			continue
+		}
+		sel := nbVars + len(assumptions) + 1
+		lits := make([]int, len(constr.Lits)+1)
+		copy(lits, constr.Lits)
+		lits[len(constr.Lits)] = -sel
+		weights := make([]int, len(constr.Lits)+1)
+		for j := range constr.Lits {
+			weights[j] = 1
+			if constr.Weights != nil {
+				weights[j] = constr.Weights[j]
+			}
+		}
+		weights[len(constr.Lits)] = constr.AtLeast
+		relaxed = append(relaxed, solver.GtEq(lits, weights, constr.AtLeast))
+		assumptions = append(assumptions, solver.IntToLit(int32(sel)))
+		indices = append(indices, i)
+	}
+	s := solver.New(solver.ParsePBConstrs(relaxed))
+	s.Verbose = pb.Options.Verbose
+	if s.Assume(assumptions) != solver.Unsat && s.Solve() == solver.Sat {
+		return nil, ErrNotUnsat
+	}
+	for i := range assumptions {
+		// Relax current constraint
+		assumptions[i] = assumptions[i].Negation()
+		if s.Assume(assumptions) != solver.Unsat && s.Solve() == solver.Sat {
+			// It is now sat; reinsert the constraint
+			assumptions[i] = assumptions[i].Negation()
+			if pb.Options.Verbose {
+				fmt.Printf("c constraint %d/%d: kept\n", i+1, len(assumptions))
+			}
+		} else if pb.Options.Verbose {
+			fmt.Printf("c constraint %d/%d: removed\n", i+1, len(assumptions))
+		}
+	}
+	mus = &PBProblem{NbVars: pb.NbVars}
+	for i, lit := range assumptions {
+		if lit.IsPositive() { // Constraint was not relaxed, so it is part of the MUS
+			mus.Constrs = append(mus.Constrs, pb.Constrs[indices[i]])
+		}
+	}
+	return mus, nil
+}
+
+// OPB returns a representation of the problem using the OPB syntax.
+func (pb *PBProblem) OPB() string {
+	lines := make([]string, 1, len(pb.Constrs)+1)
+	lines[0] = fmt.Sprintf("* #variable= %d #constraint= %d", pb.NbVars, len(pb.Constrs))
+	for _, constr := range pb.Constrs {
+		terms := make([]string, 0, 2*len(constr.Lits)+2)
+		for i, lit := range constr.Lits {
+			w := 1
+			if constr.Weights != nil {
+				w = constr.Weights[i]
+			}
+			name := fmt.Sprintf("x%d", lit)
+			if lit < 0 {
+				name = fmt.Sprintf("~x%d", -lit)
+			}
+			terms = append(terms, fmt.Sprintf("+%d", w), name)
+		}
+		terms = append(terms, ">=", fmt.Sprintf("%d ;", constr.AtLeast))
+		lines = append(lines, strings.Join(terms, " "))
+	}
+	return strings.Join(lines, "\n")
+}
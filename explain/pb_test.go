@@ -0,0 +1,41 @@
+package explain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crillab/gophersat/solver"
+)
+
+func TestPBMUS(t *testing.T) {
+	const opb = `* #variable= 4 #constraint= 5
++1 x1 +1 x2 +1 x3 +1 x4 >= 3 ;
++2 x1 +1 x2 >= 2 ;
++1 ~x1 +1 ~x2 >= 2 ;
++1 x3 +1 x4 >= 1 ;
++1 ~x3 +1 ~x4 >= 1 ;`
+	pb, err := ParseOPB(strings.NewReader(opb))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	mus, err := pb.MUS()
+	if err != nil {
+		t.Fatalf("could not compute MUS: %v", err)
+	}
+	const expected = "* #variable= 4 #constraint= 2\n+2 x1 +1 x2 >= 2 ;\n+1 ~x1 +1 ~x2 >= 2 ;"
+	if res := mus.OPB(); res != expected {
+		t.Errorf("invalid MUS: expected\n%s\ngot\n%s", expected, res)
+	}
+	sat := &PBProblem{Constrs: []solver.PBConstr{solver.AtLeast([]int{1, 2, 3}, 2), solver.AtMost([]int{1, 2, 3}, 2)}}
+	if _, err := sat.MUS(); err != ErrNotUnsat {
+		t.Errorf("expected ErrNotUnsat, got %v", err)
+	}
+	card := &PBProblem{Constrs: []solver.PBConstr{
+		solver.AtLeast([]int{1, 2, 3}, 2),
+		solver.PropClause(4, 5),
+		solver.AtMost([]int{1, 2, 3}, 1),
+	}}
+	if mus, err := card.MUS(); err != nil || len(mus.Constrs) != 2 {
+		t.Errorf("expected a MUS with 2 constraints, got %v (err=%v)", mus, err)
+	}
+}
@@ -0,0 +1,131 @@
+package explain
+
+import "fmt"
+
+// PreferredMUS returns the preferred minimal conflict of the problem with respect to the given priority order,
+// i.e a minimal unsatisfiable set of groups that involves the least important groups.
+// Groups are defined as in GroupMUS: clauses in the "" group are hard, and if pb.Groups is nil,
+// each clause is its own group, named after its index in pb.Clauses, starting from 1.
+// order must contain the name of each group exactly once, from the most important one to the least important one.
+// The returned conflict is preferred in the lexicographic sense: the most important group is not part of it
+// if there is a conflict without it, then the second most important one is not part of it if there is such a conflict
+// without it either, and so on. Groups are returned by increasing importance.
+// The conflict is computed with the QuickXplain algorithm, that splits the groups recursively
+// and thus needs far fewer SAT calls than the deletion method when the conflict is small.
+// If the problem is satisfiable, or if the hard clauses alone are unsatisfiable, the method returns ErrNotUnsat.
+func (pb *Problem) PreferredMUS(order []string) (groups []string, err error) {
+	check, prio, err := newPreferenceChecker(pb, order)
+	if err != nil {
+		return nil, err
+	}
+	// QuickXplain adds groups in order until it finds a conflict: start from the least important ones.
+	candidates := make([]int, len(prio))
+	for i := range prio {
+		candidates[i] = prio[len(prio)-1-i]
+	}
+	active := make([]bool, len(check.groups))
+	conflict := check.quickXplain(active, false, candidates)
+	groups = make([]string, len(conflict))
+	for i, g := range conflict {
+		groups[i] = check.groups[g]
+	}
+	return groups, nil
+}
+
+// PreferredDiagnosis returns the preferred diagnosis of the problem with respect to the given priority order,
+// i.e a minimal set of groups such that, if all their clauses are removed, the problem becomes satisfiable,
+// and that involves the least important groups.
+// Groups and order are defined as in PreferredMUS.
+// The problem deprived of the returned diagnosis is the preferred relaxation of the problem:
+// the most important group is part of it if it is consistent with the hard clauses, then the second most
+// important one is part of it if it is consistent with them and the first one, if it was kept, and so on.
+// Groups are returned by increasing importance.
+// If the problem is satisfiable, or if the hard clauses alone are unsatisfiable, the method returns ErrNotUnsat.
+func (pb *Problem) PreferredDiagnosis(order []string) (groups []string, err error) {
+	check, prio, err := newPreferenceChecker(pb, order)
+	if err != nil {
+		return nil, err
+	}
+	active := make([]bool, len(check.groups))
+	for _, g := range prio {
+		active[g] = true
+		if !check.sat(active) {
+			active[g] = false
+			groups = append([]string{check.groups[g]}, groups...)
+			if pb.Options.Verbose {
+				fmt.Printf("c group %q: removed\n", check.groups[g])
+			}
+		} else if pb.Options.Verbose {
+			fmt.Printf("c group %q: kept\n", check.groups[g])
+		}
+	}
+	return groups, nil
+}
+
+// newPreferenceChecker returns a groupChecker for pb, along with the indices of its groups
+// from the most important to the least important one, as given by order.
+// It returns ErrNotUnsat if the problem has no conflict.
+func newPreferenceChecker(pb *Problem, order []string) (check *groupChecker, prio []int, err error) {
+	check, err = newGroupChecker(pb)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(order) != len(check.groups) {
+		return nil, nil, fmt.Errorf("got %d groups in order for %d groups in problem", len(order), len(check.groups))
+	}
+	prio = make([]int, len(order))
+	seen := make([]bool, len(check.groups))
+	for i, name := range order {
+		g, ok := check.index[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown group %q in order", name)
+		}
+		if seen[g] {
+			return nil, nil, fmt.Errorf("group %q appears twice in order", name)
+		}
+		seen[g] = true
+		prio[i] = g
+	}
+	all := make([]bool, len(check.groups))
+	for i := range all {
+		all[i] = true
+	}
+	if check.sat(all) {
+		return nil, nil, ErrNotUnsat
+	}
+	if !check.sat(make([]bool, len(check.groups))) {
+		return nil, nil, ErrNotUnsat // Hard clauses are UNSAT by themselves
+	}
+	return check, prio, nil
+}
+
+// quickXplain returns the preferred conflict among candidates, given the background groups that are active.
+// Groups that appear first in candidates are preferably part of the conflict.
+// added is true iff some groups were added to the background since the last consistency check.
+// The background, along with all candidates, must be unsatisfiable.
+// active is left unchanged when the method returns.
+func (check *groupChecker) quickXplain(active []bool, added bool, candidates []int) []int {
+	if added && !check.sat(active) {
+		return nil
+	}
+	if len(candidates) == 1 {
+		return candidates
+	}
+	half := len(candidates) / 2
+	c1, c2 := candidates[:half], candidates[half:]
+	for _, g := range c1 {
+		active[g] = true
+	}
+	d2 := check.quickXplain(active, true, c2)
+	for _, g := range c1 {
+		active[g] = false
+	}
+	for _, g := range d2 {
+		active[g] = true
+	}
+	d1 := check.quickXplain(active, len(d2) > 0, c1)
+	for _, g := range d2 {
+		active[g] = false
+	}
+	return append(append([]int{}, d1...), d2...)
+}
@@ -0,0 +1,52 @@
+package explain
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPreferred(t *testing.T) {
+	// Vars: 1 = sunroof, 2 = roof rack, 3 = sport pack
+	var pb Problem
+	pb.AddGroup("factory: no roof rack with sunroof", [][]int{{-1, -2}})
+	pb.AddGroup("factory: sport pack includes roof rack", [][]int{{-3, 2}})
+	pb.AddGroup("user: sunroof", [][]int{{1}})
+	pb.AddGroup("user: sport pack", [][]int{{3}})
+	pb.AddGroup("user: roof rack", [][]int{{2}})
+	order := []string{
+		"factory: no roof rack with sunroof",
+		"factory: sport pack includes roof rack",
+		"user: sunroof",
+		"user: sport pack",
+		"user: roof rack",
+	}
+	mus, err := pb.PreferredMUS(order)
+	if err != nil {
+		t.Fatalf("could not compute preferred MUS: %v", err)
+	}
+	if res := fmt.Sprint(mus); res != "[user: roof rack user: sunroof factory: no roof rack with sunroof]" {
+		t.Errorf("invalid preferred MUS %q", mus)
+	}
+	diag, err := pb.PreferredDiagnosis(order)
+	if err != nil {
+		t.Fatalf("could not compute preferred diagnosis: %v", err)
+	}
+	if res := fmt.Sprint(diag); res != "[user: roof rack user: sport pack]" {
+		t.Errorf("invalid preferred diagnosis %q", diag)
+	}
+	// Reversing the order makes factory rules less important than user choices
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	if diag, err := pb.PreferredDiagnosis(order); err != nil || fmt.Sprint(diag) != "[factory: no roof rack with sunroof]" {
+		t.Errorf("invalid preferred diagnosis %q (err=%v)", diag, err)
+	}
+	if _, err := pb.PreferredMUS(order[1:]); err == nil {
+		t.Errorf("expected an error for incomplete order")
+	}
+	var sat Problem
+	sat.AddGroup("g", [][]int{{1}})
+	if _, err := sat.PreferredMUS([]string{"g"}); err != ErrNotUnsat {
+		t.Errorf("expected ErrNotUnsat, got %v", err)
+	}
+}
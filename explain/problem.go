@@ -15,7 +15,44 @@ type Problem struct {
 	NbClauses int
 	units     []int // For each var, 0 if the var is unbound, 1 if true, -1 if false
 	Options   Options
-	tagged    []bool // List of claused used whil proving the problem is unsat. Initialized lazily
+	tagged    []bool   // List of claused used whil proving the problem is unsat. Initialized lazily
+	Groups    []string // Name of the group of each clause, if any. Clauses in the "" group are hard. See GroupMUS.
+	Sources   []Source // Where each clause comes from, if known. Subsets of the problem, such as MUSes, keep them.
+}
+
+// A Source tells where a clause was defined.
+type Source struct {
+	File  string // Path of the file the clause was read from, if known
+	Line  int    // Line number of the clause in the file, starting from 1
+	Label string // Text of the comment that preceded the clause, if any
+}
+
+// String returns a representation of the source, such as "problem.cnf:12: requirement #3".
+func (src Source) String() string {
+	res := fmt.Sprintf("line %d", src.Line)
+	if src.File != "" {
+		res = fmt.Sprintf("%s:%d", src.File, src.Line)
+	}
+	if src.Label != "" {
+		res += ": " + src.Label
+	}
+	return res
+}
+
+// subset returns the subproblem made of the clauses with the given indices, along with their sources, if known.
+func (pb *Problem) subset(indices []int) *Problem {
+	clauses := make([][]int, len(indices))
+	for i, idx := range indices {
+		clauses[i] = pb.Clauses[idx]
+	}
+	sub := makeMus(pb.NbVars, clauses)
+	if pb.Sources != nil {
+		sub.Sources = make([]Source, len(indices))
+		for i, idx := range indices {
+			sub.Sources[i] = pb.Sources[idx]
+		}
+	}
+	return sub
 }
 
 func (pb *Problem) initTagged() {
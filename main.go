@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strings"
@@ -18,29 +20,33 @@ const helpString = "This is gophersat version 1.3, a SAT and Pseudo-Boolean solv
 func main() {
 	// defer profile.Start().Stop()
 	var (
-		verbose bool
-		cert    bool
-		mus     bool
-		count   bool
-		cp      bool
-		help    bool
+		verbose  bool
+		cert     bool
+		mus      bool
+		count    bool
+		backbone bool
+		cp       bool
+		veripb   string
+		help     bool
 	)
 	flag.BoolVar(&verbose, "verbose", false, "sets verbose mode on")
 	flag.BoolVar(&cert, "certified", false, "displays RUP certificate on stdout")
 	flag.BoolVar(&mus, "mus", false, "extracts a MUS from an unsat problem")
 	flag.BoolVar(&count, "count", false, "rather than solving the problem, counts the number of models it accepts")
+	flag.BoolVar(&backbone, "backbone", false, "rather than solving the problem, displays the literals that are true in all its models")
 	flag.BoolVar(&cp, "cp", false, "use cutting planes for resolution")
+	flag.StringVar(&veripb, "veripb", "", "writes a VeriPB proof of the answer in the given file, and the formula it refers to in the same file with an additional .opb extension")
 	flag.BoolVar(&help, "help", false, "displays help")
 	flag.Parse()
 	if !help && len(flag.Args()) != 1 {
 		fmt.Print(helpString)
-		fmt.Fprintf(os.Stderr, "Syntax : %s [options] (file.cnf|file.wcnf|file.bf|file.opb)\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Syntax : %s [options] (file.cnf|file.wcnf|file.bf|file.opb|file.gcnf)\n", os.Args[0])
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
 	if help {
 		fmt.Print(helpString)
-		fmt.Printf("Syntax : %s [options] (file.cnf|file.wcnf|file.bf|file.opb)\n", os.Args[0])
+		fmt.Printf("Syntax : %s [options] (file.cnf|file.wcnf|file.bf|file.opb|file.gcnf)\n", os.Args[0])
 		flag.PrintDefaults()
 		os.Exit(0)
 	}
@@ -55,31 +61,52 @@ func main() {
 				os.Exit(1)
 			}
 		} else if strings.HasSuffix(path, ".wcnf") {
-			if err := parseAndSolveWCNF(path, verbose); err != nil {
+			if err := parseAndSolveWCNF(path, verbose, veripb); err != nil {
 				fmt.Fprintf(os.Stderr, "could not parse MAXSAT file %q: %v", path, err)
 				os.Exit(1)
 			}
+		} else if count {
+			if err := parseAndCount(path, verbose); err != nil {
+				fmt.Fprintf(os.Stderr, "could not parse problem: %v\n", err)
+				os.Exit(1)
+			}
+		} else if backbone {
+			if pb, _, err := parse(path); err != nil {
+				fmt.Fprintf(os.Stderr, "could not parse problem: %v\n", err)
+				os.Exit(1)
+			} else {
+				printBackbone(pb, strings.HasSuffix(path, ".opb"))
+			}
 		} else {
 			if pb, printFn, err := parse(flag.Args()[0]); err != nil {
 				fmt.Fprintf(os.Stderr, "could not parse problem: %v\n", err)
 				os.Exit(1)
-			} else if count {
-				countModels(pb, verbose)
 			} else {
-				solve(pb, verbose, cert, cp, printFn)
+				if err := solve(pb, verbose, cert, cp, veripb, printFn); err != nil {
+					fmt.Fprintf(os.Stderr, "could not solve problem: %v\n", err)
+					os.Exit(1)
+				}
 			}
 		}
 	}
 }
 
 func extractMUS(path string) {
+	if strings.HasSuffix(path, ".gcnf") {
+		extractGroupMUS(path)
+		return
+	}
 	f, err := os.Open(path)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "could not parse problem: %v\n", err)
 		os.Exit(1)
 	}
 	defer f.Close()
-	pb, err := explain.ParseCNF(f)
+	if strings.HasSuffix(path, ".opb") {
+		extractPBMUS(f)
+		return
+	}
+	pb, err := explain.ParseCNFFile(path)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "could not parse problem: %v\n", err)
 		os.Exit(1)
@@ -89,35 +116,154 @@ func extractMUS(path string) {
 		fmt.Fprintf(os.Stderr, "could not extract subset: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Println(pb2)
-	fmt.Println(pb2.CNF())
+	printSubset(pb2)
+}
+
+// printSubset displays the given subset of a CNF problem, each clause being preceded by a comment line
+// telling where it comes from, e.g "c problem.cnf:12: requirement #3".
+func printSubset(pb *explain.Problem) {
+	lines := strings.Split(pb.CNF(), "\n")
+	fmt.Println(lines[0])
+	for i, line := range lines[1:] {
+		if pb.Sources != nil {
+			fmt.Printf("c %s\n", pb.Sources[i])
+		}
+		fmt.Println(line)
+	}
+}
+
+// extractGroupMUS displays a minimal unsatisfiable set of groups for the GCNF problem in path.
+// Each clause of these groups is listed in a comment line telling where it comes from,
+// e.g "c {3} problem.gcnf:12: requirement #3".
+func extractGroupMUS(path string) {
+	pb, err := explain.ParseGCNFFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not parse problem: %v\n", err)
+		os.Exit(1)
+	}
+	groups, err := pb.GroupMUS()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not extract subset: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("s UNSATISFIABLE")
+	inMUS := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		inMUS[group] = true
+	}
+	for i, group := range pb.Groups {
+		if inMUS[group] {
+			fmt.Printf("c {%s} %s\n", group, pb.Sources[i])
+		}
+	}
+	fmt.Printf("v %s 0\n", strings.Join(groups, " "))
+}
+
+// extractPBMUS displays a MUS for the OPB problem in f.
+func extractPBMUS(f *os.File) {
+	pb, err := explain.ParseOPB(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not parse problem: %v\n", err)
+		os.Exit(1)
+	}
+	mus, err := pb.MUS()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not extract subset: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(mus.OPB())
+}
+
+// parseAndCount counts the models of the problem in path.
+// If the problem is a CNF file with a sampling set ("c ind" lines), models are projected on that set.
+func parseAndCount(path string, verbose bool) error {
+	if !strings.HasSuffix(path, ".cnf") {
+		pb, _, err := parse(path)
+		if err != nil {
+			return err
+		}
+		countModels(pb, nil, verbose)
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open %q: %v", path, err)
+	}
+	defer f.Close()
+	pb, ind, err := solver.ParseProjectedCNF(f)
+	if err != nil {
+		return fmt.Errorf("could not parse DIMACS file %q: %v", path, err)
+	}
+	countModels(pb, ind, verbose)
+	return nil
 }
 
-func countModels(pb *solver.Problem, verbose bool) {
+// countModels prints the number of models of pb.
+// If ind is not nil, models are projected on the vars it contains.
+func countModels(pb *solver.Problem, ind []solver.Var, verbose bool) {
 	s := solver.New(pb)
 	if verbose {
 		fmt.Printf("c ======================================================================================\n")
 		fmt.Printf("c | Number of non-unit clauses : %9d                                             |\n", len(pb.Clauses))
 		fmt.Printf("c | Number of variables        : %9d                                             |\n", pb.NbVars)
+		if ind != nil {
+			fmt.Printf("c | Number of projected vars   : %9d                                             |\n", len(ind))
+		}
 		s.Verbose = true
 	}
-	models := make(chan []bool)
-	go s.Enumerate(models, nil)
-	nb := 0
-	for range models {
-		nb++
-		if verbose {
-			fmt.Printf("c %d models found\n", nb)
+	if ind != nil {
+		fmt.Println(s.CountProjected(ind))
+	} else {
+		fmt.Println(s.Count())
+	}
+}
+
+// printBackbone prints the backbone of pb, i.e the literals that are true in all its models.
+// If opb is true, literals are displayed as in OPB files, else they are displayed as in DIMACS files.
+func printBackbone(pb *solver.Problem, opb bool) {
+	backbone := solver.New(pb).Backbone()
+	if backbone == nil {
+		fmt.Println("s UNSATISFIABLE")
+		return
+	}
+	fmt.Println("s SATISFIABLE")
+	fmt.Printf("b ")
+	for _, lit := range backbone {
+		switch {
+		case !opb:
+			fmt.Printf("%d ", lit.Int())
+		case lit.IsPositive():
+			fmt.Printf("x%d ", lit.Var().Int())
+		default:
+			fmt.Printf("~x%d ", lit.Var().Int())
 		}
 	}
-	fmt.Println(nb)
+	if opb {
+		fmt.Println()
+	} else {
+		fmt.Println("0")
+	}
 }
 
-func solve(pb *solver.Problem, verbose, cert, cp bool, printFn func(chan solver.Result)) {
-	if cp {
+// solve solves pb and prints its results with printFn.
+// If proofPath is not empty, a VeriPB proof of the answer is written in that file.
+func solve(pb *solver.Problem, verbose, cert, cp bool, proofPath string, printFn func(chan solver.Result)) error {
+	if cp && proofPath == "" { // Detected constraints would not be part of the formula proofs refer to
 		pb.DetectAtMostOne()
 	}
 	s := solver.New(pb)
+	if proofPath != "" {
+		done, err := logVeriPB(proofPath, func(formula, proof io.Writer) error {
+			if err := s.WriteFormula(formula); err != nil {
+				return err
+			}
+			return s.LogVeriPB(proof)
+		})
+		if err != nil {
+			return err
+		}
+		defer done()
+	}
 	if verbose {
 		fmt.Printf("c ======================================================================================\n")
 		fmt.Printf("c | Number of non-unit clauses : %9d                                             |\n", len(pb.Clauses))
@@ -134,9 +280,34 @@ func solve(pb *solver.Problem, verbose, cert, cp bool, printFn func(chan solver.
 		fmt.Printf("c nb unit learned: %d\nc nb binary learned: %d\nc nb learned: %d\n", s.Stats.NbUnitLearned, s.Stats.NbBinaryLearned, s.Stats.NbLearned)
 		fmt.Printf("c nb learned clauses deleted: %d\n", s.Stats.NbDeleted)
 	}
+	return nil
 }
 
-func parseAndSolveWCNF(path string, verbose bool) error {
+// logVeriPB creates the file at proofPath and the file at proofPath + ".opb", and calls fn with them
+// so that a VeriPB proof is logged in the former and the formula it refers to is written in the latter.
+// The returned function must be called once the problem is solved, so that the proof file is flushed and closed.
+func logVeriPB(proofPath string, fn func(formula, proof io.Writer) error) (done func(), err error) {
+	formula, err := os.Create(proofPath + ".opb")
+	if err != nil {
+		return nil, fmt.Errorf("could not create formula file: %v", err)
+	}
+	defer formula.Close()
+	f, err := os.Create(proofPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not create proof file: %v", err)
+	}
+	proof := bufio.NewWriter(f)
+	if err := fn(formula, proof); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("could not log proof: %v", err)
+	}
+	return func() {
+		proof.Flush()
+		f.Close()
+	}, nil
+}
+
+func parseAndSolveWCNF(path string, verbose bool, proofPath string) error {
 	f, err := os.Open(path)
 	if err != nil {
 		return fmt.Errorf("could not open %q: %v", path, err)
@@ -146,6 +317,13 @@ func parseAndSolveWCNF(path string, verbose bool) error {
 	if err != nil {
 		return fmt.Errorf("could not parse wcnf content: %v", err)
 	}
+	if proofPath != "" {
+		done, err := logVeriPB(proofPath, s.(*maxsat.Solver).LogVeriPB)
+		if err != nil {
+			return err
+		}
+		defer done()
+	}
 	results := make(chan solver.Result)
 	go s.Optimal(results, nil)
 	printOptimizationResults(results)
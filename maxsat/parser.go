@@ -38,6 +38,17 @@ func (s *Solver) Optimal(results chan solver.Result, stop chan struct{}) solver.
 	return res // Last result is returned
 }
 
+// LogVeriPB writes, on formula, the problem in the OPB format, soft clauses being relaxed by relax variables
+// whose weighted sum is minimized, and makes the solver write, on proof, a VeriPB proof of its answer for that formula.
+// It must be called before the problem is solved.
+// See solver.Solver.LogVeriPB for more details.
+func (s *Solver) LogVeriPB(formula, proof io.Writer) error {
+	if err := s.solver.WriteFormula(formula); err != nil {
+		return err
+	}
+	return s.solver.LogVeriPB(proof)
+}
+
 // Enumerate does not make sense for a MAXSAT problem, so it will panic when called.
 // This might change in later versions.
 func (s *Solver) Enumerate(models chan []bool, stop chan struct{}) int {
@@ -2,6 +2,7 @@ package maxsat
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/crillab/gophersat/solver"
 )
@@ -82,6 +83,17 @@ func (pb *Problem) Solver() *solver.Solver {
 	return pb.solver
 }
 
+// LogVeriPB writes, on formula, the problem in the OPB format, soft constraints being relaxed by blocking variables
+// whose weighted sum is minimized, and makes the solver write, on proof, a VeriPB proof of its answer for that formula.
+// It must be called before the problem is solved.
+// See solver.Solver.LogVeriPB for more details.
+func (pb *Problem) LogVeriPB(formula, proof io.Writer) error {
+	if err := pb.solver.WriteFormula(formula); err != nil {
+		return err
+	}
+	return pb.solver.LogVeriPB(proof)
+}
+
 // Solve returns an optimal Model for the problem and the associated cost.
 // If the model is nil, the problem was not satisfiable (i.e hard clauses could not be satisfied).
 func (pb *Problem) Solve() (Model, int) {
@@ -98,3 +110,42 @@ func (pb *Problem) Solve() (Model, int) {
 	}
 	return res, cost
 }
+
+// Closest returns a model satisfying all hard constraints that is as close as possible to the reference model ref,
+// and its distance to ref, i.e the sum of the weights of the variables whose binding differs from ref.
+// ref can be partial: variables that do not appear in it can be bound freely.
+// weights gives the weight of each variable of ref. Variables that do not appear in weights have a weight of 1.
+// Soft constraints are ignored, and variables of ref that do not appear in the problem are ignored too.
+// If the model is nil, the problem was not satisfiable (i.e hard clauses could not be satisfied).
+func (pb *Problem) Closest(ref Model, weights map[string]int) (Model, int) {
+	lits := make([]solver.Lit, 0, len(ref))
+	litWeights := make([]int, 0, len(ref))
+	for name, binding := range ref {
+		v, ok := pb.intVars[name]
+		if !ok {
+			continue
+		}
+		lit := solver.IntToLit(int32(v))
+		if !binding {
+			lit = lit.Negation()
+		}
+		lits = append(lits, lit)
+		w, ok := weights[name]
+		if !ok {
+			w = 1
+		}
+		litWeights = append(litWeights, w)
+	}
+	res := pb.solver.Closest(lits, litWeights)
+	if res.Status != solver.Sat {
+		return nil, -1
+	}
+	model := make(Model)
+	for i, binding := range res.Model {
+		name := pb.varInts[i]
+		if name != "" { // Ignore blocking lits
+			model[name] = binding
+		}
+	}
+	return model, res.Weight
+}
@@ -1,8 +1,10 @@
 package maxsat
 
 import (
+	"bytes"
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 )
 
@@ -135,3 +137,49 @@ func BenchmarkTSP10(b *testing.B) {
 		New(generateTSP(10)...).Solve()
 	}
 }
+
+func TestClosest(t *testing.T) {
+	pb := New(
+		HardClause(Var("a"), Var("b")),
+		HardClause(Not("a"), Not("b")),
+		HardClause(Not("b"), Var("c")),
+		SoftClause(Not("c")),
+	)
+	ref := Model{"a": true, "b": true, "c": false, "unknown": true}
+	if model, cost := pb.Closest(ref, map[string]int{"b": 3}); model == nil {
+		t.Errorf("expected sat, got unsat")
+	} else if model["a"] || !model["b"] || !model["c"] {
+		t.Errorf("invalid model, got %v", model)
+	} else if cost != 2 {
+		t.Errorf("invalid cost, expected 2, got %d", cost)
+	}
+}
+
+func TestLogVeriPB(t *testing.T) {
+	pb := New(
+		HardClause(Var("a"), Var("b"), Var("c")),
+		HardPBConstr([]Lit{Not("a"), Not("b"), Not("c")}, []int{1, 1, 1}, 2),
+		WeightedClause([]Lit{Not("a"), Var("d")}, 2),
+		SoftClause(Not("c"), Not("d")),
+		SoftClause(Not("d")),
+	)
+	var formula, proof bytes.Buffer
+	if err := pb.LogVeriPB(&formula, &proof); err != nil {
+		t.Fatalf("could not log proof: %v", err)
+	}
+	if model, cost := pb.Solve(); model == nil {
+		t.Errorf("expected sat, got unsat")
+	} else if cost != 0 {
+		t.Errorf("invalid cost, expected 0, got %d", cost)
+	}
+	if !strings.Contains(formula.String(), "min: ") {
+		t.Errorf("formula has no cost function: %q", formula.String())
+	}
+	lines := strings.Split(strings.TrimSpace(proof.String()), "\n")
+	if lines[0] != "pseudo-Boolean proof version 1.2" || lines[1] != "f 5" {
+		t.Errorf("invalid proof header: %q", lines[:2])
+	}
+	if last := lines[len(lines)-1]; !strings.HasPrefix(last, "c ") {
+		t.Errorf("proof does not conclude, last line is %q", last)
+	}
+}
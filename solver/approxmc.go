@@ -0,0 +1,215 @@
+package solver
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"sort"
+)
+
+// An Estimate is an approximation of the number of models of a problem.
+type Estimate struct {
+	Count *big.Int // Estimated number of models
+	// With probability at least Confidence, the actual number of models is between Lower and Upper.
+	Lower, Upper *big.Int
+	Confidence   float64
+	Exact        bool // True iff Count is the exact number of models, in which case Lower and Upper are equal to Count
+}
+
+// ApproxCount returns an (epsilon, delta)-approximation of the number of models of the problem, projected on the
+// sampling set, i.e the number of distinct assignments of the vars in sampling that can be extended to a model.
+// If sampling is nil, all vars are considered.
+// With probability at least 1-delta, the actual count c is such that Count/(1+epsilon) <= c <= Count*(1+epsilon).
+// Typical values are epsilon = 0.8 and delta = 0.2.
+// The estimate is computed in the style of ApproxMC: random XOR constraints over the sampling set split the models
+// into cells of roughly equal size, and the models of a single cell are enumerated, up to a threshold.
+// XOR constraints are encoded as clauses. The random choices are made with the given seed, so that results are reproducible.
+// As with Count, current top-level bindings, including assumptions, are taken into account, and the state of the solver is not modified.
+// ApproxCount will panic if epsilon is not strictly positive or if delta is not in ]0, 1[.
+func (s *Solver) ApproxCount(sampling []Var, epsilon, delta float64, seed int64) Estimate {
+	if epsilon <= 0 || delta <= 0 || delta >= 1 {
+		panic("invalid epsilon or delta value")
+	}
+	if sampling == nil {
+		sampling = make([]Var, s.nbUserVars)
+		for i := range sampling {
+			sampling[i] = Var(i)
+		}
+	}
+	if s.status == Unsat {
+		return exactEstimate(0)
+	}
+	s2 := s.clone()
+	return s2.approxCount(sampling, epsilon, delta, &xorCells{s: s2}, rand.New(rand.NewSource(seed)))
+}
+
+// approxCount is the implementation of ApproxCount, with the random choices made by rng.
+// s is supposed to be a clone of the solver the user called ApproxCount on, and xors must add XORs to s.
+func (s *Solver) approxCount(sampling []Var, epsilon, delta float64, xors *xorCells, rng *rand.Rand) Estimate {
+	thresh := int(math.Ceil(1 + 9.84*(1+epsilon/(1+epsilon))*(1+1/epsilon)*(1+1/epsilon)))
+	if nb := s.boundedCount(nil, sampling, thresh); nb < thresh {
+		return exactEstimate(nb)
+	}
+	nbIter := int(math.Ceil(17 * math.Log2(3/delta)))
+	estimates := make([]*big.Int, 0, nbIter)
+	m := 1
+	for i := 0; i < nbIter; i++ {
+		var nb int
+		nb, m = s.approxCountIter(sampling, thresh, m, xors, rng)
+		estimates = append(estimates, new(big.Int).Lsh(big.NewInt(int64(nb)), uint(m)))
+	}
+	sort.Slice(estimates, func(i, j int) bool { return estimates[i].Cmp(estimates[j]) < 0 })
+	res := Estimate{Count: estimates[len(estimates)/2], Confidence: 1 - delta}
+	count := new(big.Float).SetInt(res.Count)
+	res.Lower, _ = new(big.Float).Quo(count, big.NewFloat(1+epsilon)).Int(nil)
+	res.Upper, _ = new(big.Float).Mul(count, big.NewFloat(1+epsilon)).Int(nil)
+	res.Upper.Add(res.Upper, bigOne) // Round up
+	return res
+}
+
+// exactEstimate returns an Estimate for an exact count.
+func exactEstimate(nb int) Estimate {
+	count := big.NewInt(int64(nb))
+	return Estimate{Count: count, Lower: count, Upper: count, Confidence: 1, Exact: true}
+}
+
+// approxCountIter performs one iteration of the approximate counter on s, that is supposed to be a clone of the
+// solver the user called ApproxCount on.
+// It looks for the smallest number m of random XOR constraints such that the number of models in the cell is below thresh,
+// starting at the given guess, and returns the number of models in the cell and m.
+// The XOR constraints are disabled before returning, so that s can be used for the next iteration.
+func (s *Solver) approxCountIter(sampling []Var, thresh, guess int, xors *xorCells, rng *rand.Rand) (nb, m int) {
+	// XOR constraints are generated lazily, but always in the same order,
+	// so that the cell for m+1 XORs is a subset of the cell for m XORs.
+	defer xors.reset()
+	count := func(m int) int {
+		for len(xors.sels) < m {
+			xors.add(sampling, rng)
+		}
+		return s.boundedCount(xors.sels[:m], sampling, thresh)
+	}
+	n := len(sampling)
+	if guess > n {
+		guess = n
+	}
+	counts := make(map[int]int) // Counts already computed, for each value of m
+	lo, hi := 0, -1             // count(lo) >= thresh; count(hi) < thresh, if hi != -1
+	for m := guess; hi == -1; {
+		counts[m] = count(m)
+		if counts[m] < thresh {
+			hi = m
+		} else if m == n { // No more XORs can be added
+			return counts[m], m
+		} else {
+			lo = m
+			m *= 2
+			if m > n {
+				m = n
+			}
+		}
+	}
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		counts[mid] = count(mid)
+		if counts[mid] < thresh {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return counts[hi], hi
+}
+
+// xorCells adds random XOR constraints to a solver, in order to split its models into cells.
+// Each XOR is only active when its selector is assumed.
+// XORs are encoded as chains of 2-var XORs, defining auxiliary vars. Those vars are recycled:
+// once the XORs that defined them are disabled, their defining clauses are satisfied for good,
+// so the vars can be used by the next XORs. This keeps the number of vars low, even after many XORs.
+type xorCells struct {
+	s     *Solver
+	sels  []Lit // Selectors of the current XORs
+	aux   []Lit // Auxiliary vars created so far
+	nbAux int   // Number of auxiliary vars used by the current XORs
+}
+
+// add adds a random XOR constraint over the given vars, and returns its selector.
+// Each var appears in the constraint with probability 1/2, and the parity of the constraint is also random.
+func (x *xorCells) add(vars []Var, rng *rand.Rand) Lit {
+	s := x.s
+	sel := s.newSelector()
+	x.sels = append(x.sels, sel)
+	var acc Lit // Lit whose value is the XOR of all the vars met so far
+	first := true
+	for _, v := range vars {
+		if rng.Intn(2) == 0 {
+			continue
+		}
+		if first {
+			acc = v.Lit()
+			first = false
+			continue
+		}
+		if x.nbAux == len(x.aux) {
+			x.aux = append(x.aux, s.newSelector())
+		}
+		y := x.aux[x.nbAux] // Auxiliary var: y <=> acc xor v
+		x.nbAux++
+		a, b, off := acc, v.Lit(), sel.Negation()
+		s.AppendClause(NewClause([]Lit{off, y.Negation(), a, b}))
+		s.AppendClause(NewClause([]Lit{off, y.Negation(), a.Negation(), b.Negation()}))
+		s.AppendClause(NewClause([]Lit{off, y, a.Negation(), b}))
+		s.AppendClause(NewClause([]Lit{off, y, a, b.Negation()}))
+		acc = y
+	}
+	parity := rng.Intn(2) == 1
+	switch {
+	case first && parity: // Empty XOR that must be true: cell is empty
+		s.AppendClause(NewClause([]Lit{sel.Negation()}))
+	case first: // Empty XOR that must be false: always satisfied
+	case parity:
+		s.AppendClause(NewClause([]Lit{sel.Negation(), acc}))
+	default:
+		s.AppendClause(NewClause([]Lit{sel.Negation(), acc.Negation()}))
+	}
+	return sel
+}
+
+// reset disables all the current XORs, for good.
+func (x *xorCells) reset() {
+	for _, sel := range x.sels {
+		x.s.disableSelector(sel)
+	}
+	x.sels = x.sels[:0]
+	x.nbAux = 0
+}
+
+// boundedCount returns the number of models of s under the given assumptions, projected on the given vars,
+// or limit if there are at least limit such models.
+func (s *Solver) boundedCount(assumptions []Lit, vars []Var, limit int) int {
+	return len(s.boundedModels(assumptions, vars, limit))
+}
+
+// boundedModels returns the models of s under the given assumptions, distinct with respect to the given vars,
+// up to limit models.
+// Models are enumerated by adding blocking clauses, that are disabled before returning.
+func (s *Solver) boundedModels(assumptions []Lit, vars []Var, limit int) [][]bool {
+	block := s.newSelector()
+	assumed := make([]Lit, len(assumptions)+1)
+	copy(assumed, assumptions)
+	assumed[len(assumptions)] = block
+	var models [][]bool
+	for len(models) < limit {
+		if s.Assume(assumed) == Unsat || s.Solve() != Sat {
+			break
+		}
+		models = append(models, s.Model())
+		lits := make([]Lit, len(vars)+1)
+		for i, v := range vars {
+			lits[i] = v.SignedLit(s.model[v] > 0)
+		}
+		lits[len(vars)] = block.Negation()
+		s.AppendClause(NewClause(lits))
+	}
+	s.disableSelector(block)
+	return models
+}
@@ -0,0 +1,98 @@
+package solver
+
+import "sort"
+
+// backboneChunk is the max number of candidate lits that are checked together by a single SAT call.
+const backboneChunk = 16
+
+// Backbone returns the backbone of the problem, i.e the lits that are true in all models, sorted by var.
+// A var that is bound to true (resp. false) in all models appears positively (resp. negatively) in the backbone;
+// a var that is true in some models and false in others does not appear in it.
+// Backbone returns nil if the problem is UNSAT, and an empty, non-nil slice if it is SAT but its backbone is empty.
+// The backbone is computed by iterative SAT calls under assumptions. Candidates are the lits of a first model.
+// Each subsequent model removes from the candidates the lits it falsifies, and the search is guided towards models
+// that falsify as many candidates as possible. Candidates are checked by chunks: a single SAT call assumes
+// all the lits in the chunk are false. If that is not possible, the failed assumptions tell which lits
+// are responsible: if there is only one, it belongs to the backbone and is added as a unit, which helps later calls.
+// Else, only those lits are checked one at a time, and the others stay in chunks.
+// As with Count, current top-level bindings, including assumptions, are taken into account, and the state of the solver is not modified.
+func (s *Solver) Backbone() []Lit {
+	if s.status == Unsat {
+		return nil
+	}
+	s2 := s.clone()
+	if s2.Solve() != Sat {
+		return nil
+	}
+	backbone := []Lit{}
+	var cands []Lit
+	for v := 0; v < s.nbUserVars; v++ {
+		lit := Var(v).SignedLit(s2.model[v] < 0)
+		if abs(s2.model[v]) == 1 { // Top-level bindings are part of the backbone
+			backbone = append(backbone, lit)
+			continue
+		}
+		cands = append(cands, lit)
+		s2.polarity[v] = !lit.IsPositive() // Look for models that falsify candidates
+	}
+	alone := make([]bool, s.nbUserVars) // Candidates that must be checked one at a time; they are at the end of cands
+	for len(cands) > 0 {
+		size := 1
+		if !alone[cands[len(cands)-1].Var()] {
+			size = backboneChunk
+			if size > len(cands) {
+				size = len(cands)
+			}
+		}
+		chunk := cands[len(cands)-size:]
+		assumed := make([]Lit, size)
+		for i, lit := range chunk {
+			assumed[i] = lit.Negation()
+		}
+		if s2.Assume(assumed) != Unsat && s2.Solve() == Sat {
+			// Filter candidates falsified by the new model.
+			j := 0
+			for _, lit := range cands {
+				if (s2.model[lit.Var()] > 0) == lit.IsPositive() {
+					cands[j] = lit
+					j++
+				}
+			}
+			cands = cands[:j]
+			continue
+		}
+		core := s2.FailedAssumptions()
+		if size == 1 || len(core) == 1 { // The lit cannot be false
+			lit := chunk[0]
+			if len(core) == 1 {
+				lit = core[0].Negation()
+			}
+			backbone = append(backbone, lit)
+			s2.AppendClause(NewClause([]Lit{lit}))
+			cands = removeLit(cands, lit)
+			continue
+		}
+		if len(core) == 0 { // Not analyzed: check the whole chunk one lit at a time
+			for _, lit := range chunk {
+				core = append(core, lit.Negation())
+			}
+		}
+		// The lits of the core cannot all be false at once, but each of them might be: check them one at a time.
+		for _, lit := range core {
+			cands = append(removeLit(cands, lit.Negation()), lit.Negation())
+			alone[lit.Var()] = true
+		}
+	}
+	sort.Slice(backbone, func(i, j int) bool { return backbone[i].Var() < backbone[j].Var() })
+	return backbone
+}
+
+// removeLit removes lit from lits, if it is present, and keeps the order of the other lits.
+func removeLit(lits []Lit, lit Lit) []Lit {
+	for i, l := range lits {
+		if l == lit {
+			return append(lits[:i], lits[i+1:]...)
+		}
+	}
+	return lits
+}
@@ -0,0 +1,57 @@
+package solver
+
+import "fmt"
+
+// Closest returns a model of the problem that is as close as possible to the reference assignment ref,
+// i.e a model minimizing the weighted Hamming distance to ref.
+// ref is a list of literals that should ideally be true. It can be partial: variables that do not appear in ref
+// can be bound freely. weights associates a strictly positive weight to each literal in ref, i.e the cost of
+// falsifying it. If weights is nil, all literals have a weight of 1.
+// The returned Result's Weight is the distance between the model and ref. The problem's own cost function, if any,
+// is ignored. If the problem is UNSAT, the returned Result has the Unsat status.
+// The solver can be used as usual afterwards.
+// Closest will panic if weights is not nil and its length is not the same as ref's, or if ref contains
+// a variable that does not belong to the problem.
+func (s *Solver) Closest(ref []Lit, weights []int) Result {
+	if weights != nil && len(weights) != len(ref) {
+		panic(fmt.Sprintf("got %d weights for %d literals", len(weights), len(ref)))
+	}
+	if s.trivialUnsat {
+		return Result{Status: Unsat}
+	}
+	minLits := make([]Lit, len(ref))
+	for i, lit := range ref {
+		if int(lit.Var()) >= s.nbUserVars {
+			panic(fmt.Sprintf("variable %d does not belong to the problem", lit.Var().Int()))
+		}
+		minLits[i] = lit.Negation()
+	}
+	oldPolarity := make([]bool, len(ref))
+	for i, lit := range ref {
+		oldPolarity[i] = s.polarity[lit.Var()]
+		s.polarity[lit.Var()] = lit.IsPositive() // Start search from the reference
+	}
+	var minWeights []int
+	if weights != nil {
+		minWeights = make([]int, len(weights))
+		copy(minWeights, weights)
+	}
+	oldLits, oldWeights := s.minLits, s.minWeights
+	s.minLits, s.minWeights = minLits, minWeights
+	res := s.optimalUnder(nil)
+	s.minLits, s.minWeights = oldLits, oldWeights
+	for i := len(ref) - 1; i >= 0; i-- { // Reverse order, in case a var appears several times in ref
+		s.polarity[ref[i].Var()] = oldPolarity[i]
+	}
+	return res
+}
+
+// ClosestModel is like Closest, but the reference is a complete assignment of the problem's variables,
+// as returned by Model.
+func (s *Solver) ClosestModel(model []bool, weights []int) Result {
+	ref := make([]Lit, len(model))
+	for i, binding := range model {
+		ref[i] = Var(i).SignedLit(!binding)
+	}
+	return s.Closest(ref, weights)
+}
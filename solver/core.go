@@ -0,0 +1,92 @@
+package solver
+
+import "time"
+
+// FailedAssumptions returns the subset of the current assumptions that made the problem UNSAT,
+// if the last call to Assume or Solve returned Unsat.
+// Those assumptions are enough for the problem to be UNSAT, although they are not necessarily minimal.
+// If the problem is UNSAT regardless of the assumptions, or if it was not proven UNSAT, the returned slice is empty.
+// This is typically used when assumptions are selectors guarding clauses: the returned selectors then tell
+// which clauses were used to prove the problem is UNSAT.
+func (s *Solver) FailedAssumptions() []Lit {
+	if s.status != Unsat {
+		return nil
+	}
+	res := make([]Lit, len(s.core))
+	copy(res, s.core)
+	return res
+}
+
+// SetBudget limits the work done by each subsequent call to Solve.
+// Solve stops after maxConflicts conflicts or once deadline is reached, whichever comes first, and then returns Indet.
+// A call to Solve after an interrupted one resumes the search, with a new budget of maxConflicts conflicts.
+// A maxConflicts of 0 or a zero deadline mean there is no limit on conflicts or on time, respectively.
+func (s *Solver) SetBudget(maxConflicts int, deadline time.Time) {
+	s.maxConflicts = maxConflicts
+	s.deadline = deadline
+}
+
+// budgetExceeded returns true iff the current call to Solve must stop.
+func (s *Solver) budgetExceeded() bool {
+	if s.maxConflicts > 0 && s.Stats.NbConflicts-s.budgetStart >= s.maxConflicts {
+		return true
+	}
+	return !s.deadline.IsZero() && time.Now().After(s.deadline)
+}
+
+// analyzeFinal computes the failed assumptions after a top-level conflict, i.e the assumptions that
+// were used to falsify the given lits, and stores them in s.core.
+// If lits is nil or some of them are not false, the conflict cannot be analyzed and all assumptions are considered as failed.
+func (s *Solver) analyzeFinal(lits []Lit) {
+	s.core = nil
+	for _, lit := range lits {
+		if s.litStatus(lit) != Unsat {
+			lits = nil
+			break
+		}
+	}
+	if lits == nil {
+		s.core = s.allAssumptions()
+		return
+	}
+	seen := make([]bool, s.nbVars)
+	stack := make([]Lit, len(lits))
+	copy(stack, lits)
+	for len(stack) > 0 {
+		lit := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		v := lit.Var()
+		if seen[v] || s.litStatus(lit) != Unsat { // True lits in cardinality and PB reasons are not responsible
+			continue
+		}
+		seen[v] = true
+		if s.assumptions[v] {
+			s.core = append(s.core, v.SignedLit(s.model[v] < 0))
+			continue
+		}
+		reason := s.reason[v]
+		if reason == nil {
+			if abs(s.model[v]) > 1 { // Decision: cannot happen in a top-level conflict, but let's be safe
+				s.core = s.allAssumptions()
+				return
+			}
+			continue // Top-level unit, holds no matter the assumptions
+		}
+		for i := 0; i < reason.Len(); i++ {
+			if l := reason.Get(i); l.Var() != v {
+				stack = append(stack, l)
+			}
+		}
+	}
+}
+
+// allAssumptions returns all the current assumptions.
+func (s *Solver) allAssumptions() []Lit {
+	var res []Lit
+	for v, assumed := range s.assumptions {
+		if assumed {
+			res = append(res, Var(v).SignedLit(s.model[v] < 0))
+		}
+	}
+	return res
+}
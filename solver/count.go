@@ -0,0 +1,561 @@
+package solver
+
+import (
+	"encoding/binary"
+	"math/big"
+	"sort"
+)
+
+// Count returns the exact number of models of the problem, as a big.Int.
+// Current top-level bindings, including assumptions, are taken into account.
+// Contrary to CountModels, Count does not enumerate models one by one: it is an exact model counter
+// in the spirit of sharpSAT, that decomposes the problem into independent connected components,
+// caches the number of models of each component and learns clauses from conflicts.
+// The state of the solver is not modified, so it can still be used afterwards.
+func (s *Solver) Count() *big.Int {
+	if s.status == Unsat {
+		return new(big.Int)
+	}
+	c := newCounter(s, intRing{})
+	return c.count().(*big.Int)
+}
+
+// CountProjected returns the exact number of models of the problem projected on the given vars,
+// i.e the number of distinct assignments of those vars that can be extended to a model.
+// This is typically useful when the problem contains auxiliary variables, such as those introduced by a Tseitin transformation.
+// As with Count, current top-level bindings, including assumptions, are taken into account,
+// and the state of the solver is not modified.
+func (s *Solver) CountProjected(vars []Var) *big.Int {
+	if s.status == Unsat {
+		return new(big.Int)
+	}
+	c := newCounter(s, intRing{})
+	c.proj = make([]bool, s.nbVars)
+	for _, v := range vars {
+		c.proj[v] = true
+	}
+	return c.count().(*big.Int)
+}
+
+// A cntConstr is a constraint, as seen by the counter.
+type cntConstr struct {
+	lits    []Lit
+	weights []int // Weight of each lit. If nil, all weights are 1.
+	card    int   // Cardinality of the constraint.
+	maxW    int   // Greatest weight in the constraint.
+	slack   int   // Sum of the weights of all non-false lits, minus card.
+	sum     int   // Sum of the weights of all true lits.
+}
+
+// weight returns the weight of the ith lit of the constraint.
+func (c *cntConstr) weight(i int) int {
+	if c.weights == nil {
+		return 1
+	}
+	return c.weights[i]
+}
+
+// satisfied returns true iff the constraint is satisfied by the current bindings.
+func (c *cntConstr) satisfied() bool {
+	return c.sum >= c.card
+}
+
+// An occurrence is the appearance of a lit in a constraint.
+type occurrence struct {
+	constr int // Index of the constraint
+	weight int // Weight of the lit in the constraint
+}
+
+// A component is a set of unbound vars that are connected through active (i.e not yet satisfied) constraints,
+// and that can thus be counted independently of the rest of the problem.
+type component struct {
+	vars []Var
+	key  string // Representation of the component, used as a key in the cache
+	best Var    // Var to branch on first
+	proj bool   // True iff the component contains at least one projected var
+}
+
+const (
+	maxLearnedCount = 20_000 // Max number of learned clauses before the learned database is reduced
+	cntVarDecay     = 0.95   // Decay of var activity in the counter
+)
+
+// A counter is an exact model counter.
+// It performs a DPLL-style search and, after each decision, splits the remaining problem into
+// independent components whose numbers of models are multiplied.
+// The number of models of each component is cached, so that it is only computed once.
+// Clauses are learned from conflicts, but they are only used for propagation, not to compute components.
+type counter struct {
+	nbVars   int
+	constrs  []cntConstr    // Original constraints, followed by learned clauses
+	nbOrig   int            // Number of original constraints
+	occurs   [][]occurrence // For each lit, the constraints it appears in
+	binding  []int8         // For each var, 1 if it is bound to true, -1 if bound to false, 0 if unbound
+	level    []int          // For each bound var, its decision level
+	reason   []int          // For each bound var, the index of the constraint that propagated it, or -1
+	pos      []int          // For each bound var, its position in the trail
+	trail    []Lit          // Bound lits, in binding order
+	qhead    int            // Index of the next lit to propagate in the trail
+	lvl      int            // Current decision level
+	activity []float64      // Activity of each var, bumped when the var takes part in a conflict
+	varInc   float64        // Current bump value for activity
+	ring     semiring       // How values of sets of models are computed
+	proj     []bool         // For each var, whether models are projected on it. If nil, all vars are projected.
+	cache    map[string]value
+	inComp   []int // For each var, the last time it was marked as belonging to a component
+	cmpStamp int
+	cacheLog []string // Keys inserted in the cache, in insertion order
+	seen     []bool   // Buffer used during conflict analysis and component computation
+	stamp    []int    // For each constraint, last time it was visited during component computation
+	curStamp int
+	score    []int // Buffer for var scores during component computation
+}
+
+// newCounter returns a counter for the constraints and top-level bindings of s, that will compute values in the given semiring.
+func newCounter(s *Solver, ring semiring) *counter {
+	c := &counter{
+		ring:     ring,
+		inComp:   make([]int, s.nbVars),
+		nbVars:   s.nbVars,
+		occurs:   make([][]occurrence, 2*s.nbVars),
+		binding:  make([]int8, s.nbVars),
+		level:    make([]int, s.nbVars),
+		reason:   make([]int, s.nbVars),
+		pos:      make([]int, s.nbVars),
+		activity: make([]float64, s.nbVars),
+		varInc:   1,
+		cache:    make(map[string]value),
+		seen:     make([]bool, s.nbVars),
+		score:    make([]int, s.nbVars),
+	}
+	for _, clause := range s.wl.origClauses {
+		constr := cntConstr{lits: make([]Lit, clause.Len()), card: clause.Cardinality()}
+		copy(constr.lits, clause.lits)
+		if clause.PseudoBoolean() {
+			constr.weights = make([]int, clause.Len())
+			copy(constr.weights, clause.pbData.weights)
+		}
+		c.addConstr(constr)
+	}
+	c.nbOrig = len(c.constrs)
+	for v, lvl := range s.model {
+		if abs(lvl) == 1 {
+			c.assign(Var(v).SignedLit(lvl < 0), -1)
+		}
+	}
+	return c
+}
+
+// addConstr adds the given constraint to the counter and returns its index.
+// Its counters are initialized with respect to the current, fully propagated, bindings.
+func (c *counter) addConstr(constr cntConstr) int {
+	idx := len(c.constrs)
+	constr.slack = -constr.card
+	for i, lit := range constr.lits {
+		w := constr.weight(i)
+		if w > constr.maxW {
+			constr.maxW = w
+		}
+		switch c.litBinding(lit) {
+		case 1:
+			constr.sum += w
+			constr.slack += w
+		case 0:
+			constr.slack += w
+		}
+		c.occurs[lit] = append(c.occurs[lit], occurrence{constr: idx, weight: w})
+	}
+	c.constrs = append(c.constrs, constr)
+	return idx
+}
+
+// projected returns true iff models are projected on v.
+func (c *counter) projected(v Var) bool {
+	return c.proj == nil || c.proj[v]
+}
+
+// litValue returns the value of lit when it is true.
+// Lits whose var is not projected are ignored, i.e their value is one.
+func (c *counter) litValue(lit Lit) value {
+	if !c.projected(lit.Var()) {
+		return c.ring.one()
+	}
+	return c.ring.lit(lit)
+}
+
+// litBinding returns 1 if lit is true, -1 if it is false, 0 if it is unbound.
+func (c *counter) litBinding(lit Lit) int8 {
+	b := c.binding[lit.Var()]
+	if lit.IsPositive() {
+		return b
+	}
+	return -b
+}
+
+// assign binds lit to true at the current level, because of the given reason.
+func (c *counter) assign(lit Lit, reason int) {
+	v := lit.Var()
+	if lit.IsPositive() {
+		c.binding[v] = 1
+	} else {
+		c.binding[v] = -1
+	}
+	c.level[v] = c.lvl
+	c.reason[v] = reason
+	c.pos[v] = len(c.trail)
+	c.trail = append(c.trail, lit)
+}
+
+// propagate performs unit propagation and returns the index of a conflicting constraint, or -1.
+func (c *counter) propagate() int {
+	confl := -1
+	for confl == -1 && c.qhead < len(c.trail) {
+		lit := c.trail[c.qhead]
+		c.qhead++
+		for _, occ := range c.occurs[lit] {
+			c.constrs[occ.constr].sum += occ.weight
+		}
+		// All occurrences must be updated, even after a conflict, so that undo restores consistent counters.
+		for _, occ := range c.occurs[lit.Negation()] {
+			constr := &c.constrs[occ.constr]
+			constr.slack -= occ.weight
+			if confl != -1 {
+				continue
+			}
+			if constr.slack < 0 {
+				confl = occ.constr
+			} else if constr.slack < constr.maxW {
+				for i, lit2 := range constr.lits {
+					if constr.weight(i) > constr.slack && c.litBinding(lit2) == 0 {
+						c.assign(lit2, occ.constr)
+					}
+				}
+			}
+		}
+	}
+	return confl
+}
+
+// undo unbinds all lits bound after the given trail position.
+func (c *counter) undo(pos int) {
+	for i := len(c.trail) - 1; i >= pos; i-- {
+		lit := c.trail[i]
+		if i < c.qhead {
+			for _, occ := range c.occurs[lit] {
+				c.constrs[occ.constr].sum -= occ.weight
+			}
+			for _, occ := range c.occurs[lit.Negation()] {
+				c.constrs[occ.constr].slack += occ.weight
+			}
+		}
+		c.binding[lit.Var()] = 0
+	}
+	c.trail = c.trail[:pos]
+	if c.qhead > pos {
+		c.qhead = pos
+	}
+}
+
+// explain appends to lits the negation of the lits of constr that were falsified before trail position pos,
+// i.e the lits that explain why the constraint propagated a lit or became conflicting.
+// Lits bound at level 0 are ignored, as they are implied by the problem.
+func (c *counter) explain(constr *cntConstr, pos int, lits []Lit) []Lit {
+	for _, lit := range constr.lits {
+		v := lit.Var()
+		if c.litBinding(lit) == -1 && c.pos[v] < pos && c.level[v] > 0 {
+			lits = append(lits, lit)
+		}
+	}
+	return lits
+}
+
+// analyze returns a clause learned from the given conflict, using the first UIP scheme.
+func (c *counter) analyze(confl int) []Lit {
+	learned := []Lit{0} // Make room for the asserting literal
+	nbLvl := 0          // Number of lits from the current level still to be resolved
+	var buf []Lit
+	buf = c.explain(&c.constrs[confl], len(c.trail), buf)
+	idx := len(c.trail) - 1
+	var uip Lit
+	for {
+		for _, lit := range buf {
+			v := lit.Var()
+			if c.seen[v] {
+				continue
+			}
+			c.seen[v] = true
+			c.bumpVar(v)
+			if c.level[v] == c.lvl {
+				nbLvl++
+			} else {
+				learned = append(learned, lit)
+			}
+		}
+		for !c.seen[c.trail[idx].Var()] {
+			idx--
+		}
+		uip = c.trail[idx]
+		idx--
+		c.seen[uip.Var()] = false
+		nbLvl--
+		if nbLvl == 0 {
+			break
+		}
+		buf = c.explain(&c.constrs[c.reason[uip.Var()]], c.pos[uip.Var()], buf[:0])
+	}
+	learned[0] = uip.Negation()
+	for _, lit := range learned[1:] {
+		c.seen[lit.Var()] = false
+	}
+	c.varInc /= cntVarDecay
+	return learned
+}
+
+// bumpVar increases the activity of the given var.
+func (c *counter) bumpVar(v Var) {
+	c.activity[v] += c.varInc
+	if c.activity[v] > 1e100 {
+		for i := range c.activity {
+			c.activity[i] *= 1e-100
+		}
+		c.varInc *= 1e-100
+	}
+}
+
+// reduceLearned removes half of the learned clauses, keeping the shortest ones and those
+// that are the reason for a current binding.
+func (c *counter) reduceLearned() {
+	locked := make([]bool, len(c.constrs)-c.nbOrig)
+	for _, lit := range c.trail {
+		if r := c.reason[lit.Var()]; r >= c.nbOrig {
+			locked[r-c.nbOrig] = true
+		}
+	}
+	idx := make([]int, len(locked))
+	for i := range idx {
+		idx[i] = i + c.nbOrig
+	}
+	sort.SliceStable(idx, func(i, j int) bool { return len(c.constrs[idx[i]].lits) < len(c.constrs[idx[j]].lits) })
+	keep := make([]bool, len(locked))
+	for i, id := range idx {
+		keep[id-c.nbOrig] = i < len(idx)/2 || locked[id-c.nbOrig]
+	}
+	newIdx := make([]int, len(locked))
+	kept := c.constrs[:c.nbOrig]
+	for i := range locked {
+		if keep[i] {
+			newIdx[i] = len(kept)
+			kept = append(kept, c.constrs[c.nbOrig+i])
+		}
+	}
+	c.constrs = kept
+	for lit, occs := range c.occurs {
+		j := 0
+		for _, occ := range occs {
+			if occ.constr < c.nbOrig {
+				occs[j] = occ
+				j++
+			} else if keep[occ.constr-c.nbOrig] {
+				occs[j] = occurrence{constr: newIdx[occ.constr-c.nbOrig], weight: occ.weight}
+				j++
+			}
+		}
+		c.occurs[lit] = occs[:j]
+	}
+	for _, lit := range c.trail {
+		if r := c.reason[lit.Var()]; r >= c.nbOrig {
+			c.reason[lit.Var()] = newIdx[r-c.nbOrig]
+		}
+	}
+}
+
+// components splits the given vars into independent components.
+// Bound vars are ignored, and unbound vars that appear in no active constraint are not part of any component:
+// they are returned as free vars.
+func (c *counter) components(vars []Var) (comps []component, free []Var) {
+	c.curStamp++
+	if c.stamp == nil || len(c.stamp) < c.nbOrig {
+		c.stamp = make([]int, c.nbOrig)
+	}
+	var met []Var // All vars met so far, whose seen and score values must be reset
+	for _, v := range vars {
+		if c.binding[v] != 0 || c.seen[v] {
+			continue
+		}
+		c.seen[v] = true
+		compVars := []Var{v}
+		var constrs []int
+		for i := 0; i < len(compVars); i++ {
+			v2 := compVars[i]
+			for _, lit := range []Lit{v2.Lit(), v2.Lit().Negation()} {
+				for _, occ := range c.occurs[lit] {
+					if occ.constr >= c.nbOrig || c.stamp[occ.constr] == c.curStamp {
+						continue
+					}
+					constr := &c.constrs[occ.constr]
+					if constr.satisfied() {
+						continue
+					}
+					c.stamp[occ.constr] = c.curStamp
+					constrs = append(constrs, occ.constr)
+					for _, lit2 := range constr.lits {
+						v3 := lit2.Var()
+						if c.binding[v3] != 0 {
+							continue
+						}
+						c.score[v3]++
+						if !c.seen[v3] {
+							c.seen[v3] = true
+							compVars = append(compVars, v3)
+						}
+					}
+				}
+			}
+		}
+		met = append(met, compVars...)
+		if len(constrs) == 0 {
+			free = append(free, v)
+			continue
+		}
+		comps = append(comps, c.newComponent(compVars, constrs))
+	}
+	for _, v := range met {
+		c.seen[v] = false
+		c.score[v] = 0
+	}
+	sort.SliceStable(comps, func(i, j int) bool { return len(comps[i].vars) < len(comps[j].vars) })
+	return comps, free
+}
+
+// newComponent returns the component made of the given vars and active constraints.
+// It also chooses the var to branch on, based on the current var scores.
+func (c *counter) newComponent(vars []Var, constrs []int) component {
+	sort.Slice(vars, func(i, j int) bool { return vars[i] < vars[j] })
+	sort.Ints(constrs)
+	best := vars[0]
+	bestScore := -1.0
+	proj := false // Projected vars, if any, must be branched on first
+	buf := make([]byte, 0, 2*binary.MaxVarintLen32*(len(vars)+len(constrs)))
+	buf = binary.AppendUvarint(buf, uint64(len(vars)))
+	for _, v := range vars {
+		buf = binary.AppendUvarint(buf, uint64(v))
+		if c.projected(v) && !proj {
+			proj = true
+			bestScore = -1
+		}
+		if c.projected(v) != proj {
+			continue
+		}
+		if score := float64(c.score[v]) + c.activity[v]; score > bestScore {
+			best, bestScore = v, score
+		}
+	}
+	for _, idx := range constrs {
+		constr := &c.constrs[idx]
+		buf = binary.AppendUvarint(buf, uint64(idx))
+		if constr.weights != nil || constr.card != 1 {
+			buf = binary.AppendUvarint(buf, uint64(constr.card-constr.sum))
+		}
+	}
+	return component{vars: vars, key: string(buf), best: best, proj: proj}
+}
+
+// forget removes from the cache all entries inserted since the given position in the cache log.
+// This is necessary when a set of components turns out to be UNSAT: as learned clauses can propagate literals
+// across components, the counts of the other components might then be underestimated.
+func (c *counter) forget(mark int) {
+	for _, key := range c.cacheLog[mark:] {
+		delete(c.cache, key)
+	}
+	c.cacheLog = c.cacheLog[:mark]
+}
+
+// countComponents returns the value of the models of the given vars, that are all unbound.
+func (c *counter) countComponents(vars []Var) value {
+	comps, free := c.components(vars)
+	mark := len(c.cacheLog)
+	res := c.ring.one()
+	for _, comp := range comps {
+		val := c.countComponent(comp)
+		if c.ring.isZero(val) {
+			c.forget(mark)
+			return val
+		}
+		res = c.ring.mul(res, val)
+	}
+	for _, v := range free {
+		if c.projected(v) {
+			res = c.ring.mul(res, c.ring.add(c.ring.lit(v.Lit()), c.ring.lit(v.Lit().Negation())))
+		}
+	}
+	return res
+}
+
+// countComponent returns the value of the models of the given component.
+func (c *counter) countComponent(comp component) value {
+	if val, ok := c.cache[comp.key]; ok {
+		return val
+	}
+	lit := comp.best.Lit()
+	var res value
+	if comp.proj {
+		res = c.ring.add(c.countBranch(lit, comp.vars), c.countBranch(lit.Negation(), comp.vars))
+	} else { // No projected var: we only need to know whether the component is satisfiable
+		if res = c.countBranch(lit, comp.vars); c.ring.isZero(res) {
+			res = c.countBranch(lit.Negation(), comp.vars)
+		}
+	}
+	c.cache[comp.key] = res
+	c.cacheLog = append(c.cacheLog, comp.key)
+	return res
+}
+
+// countBranch returns the value of the models of the given vars once lit was decided.
+func (c *counter) countBranch(lit Lit, vars []Var) value {
+	pos := len(c.trail)
+	c.lvl++
+	defer func() { c.lvl-- }()
+	c.assign(lit, -1)
+	if confl := c.propagate(); confl != -1 {
+		learned := c.analyze(confl)
+		c.undo(pos)
+		if len(c.constrs)-c.nbOrig >= maxLearnedCount {
+			c.reduceLearned()
+		}
+		c.addConstr(cntConstr{lits: learned, card: 1})
+		return c.ring.zero()
+	}
+	// Only lits from the component are taken into account:
+	// learned clauses might have propagated lits from other components, that will be dealt with later.
+	c.cmpStamp++
+	for _, v := range vars {
+		c.inComp[v] = c.cmpStamp
+	}
+	res := c.ring.one()
+	for _, lit := range c.trail[pos:] {
+		if c.inComp[lit.Var()] == c.cmpStamp {
+			res = c.ring.mul(res, c.litValue(lit))
+		}
+	}
+	res = c.ring.mul(res, c.countComponents(vars))
+	c.undo(pos)
+	return res
+}
+
+// count returns the value of the models of the problem.
+func (c *counter) count() value {
+	if c.propagate() != -1 {
+		return c.ring.zero()
+	}
+	res := c.ring.one()
+	vars := make([]Var, 0, c.nbVars)
+	for v := 0; v < c.nbVars; v++ {
+		if c.binding[v] == 0 {
+			vars = append(vars, Var(v))
+		}
+	}
+	for _, lit := range c.trail {
+		res = c.ring.mul(res, c.litValue(lit))
+	}
+	return c.ring.mul(res, c.countComponents(vars))
+}
@@ -0,0 +1,386 @@
+package solver
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	clauses := []CardConstr{
+		AtLeast1(1, 2, 3),
+		AtLeast1(-1, -2, -3),
+		AtLeast1(2, 3, 4),
+		AtLeast1(2, 3, 5),
+		AtLeast1(3, 4, 5),
+		AtLeast1(2, 4, 5),
+	}
+	s := New(ParseCardConstrs(clauses))
+	if nb := s.Count(); nb.Cmp(big.NewInt(17)) != 0 {
+		t.Errorf("Invalid #models: expected %d, got %v", 17, nb)
+	}
+	s.Assume([]Lit{IntToLit(1)})
+	if nb := s.Count(); nb.Cmp(big.NewInt(7)) != 0 {
+		t.Errorf("Invalid #models under assumption: expected %d, got %v", 7, nb)
+	}
+}
+
+func TestCountPB(t *testing.T) {
+	pb1 := AtMost([]int{1, 2, 3, 4}, 3)
+	pb2 := AtLeast([]int{1, 2, 3, 4}, 2)
+	pb3 := GtEq([]int{2, 3, 4}, []int{1, 1, 2}, 3)
+	s := New(ParsePBConstrs([]PBConstr{pb1, pb2, pb3}))
+	if nb := s.Count(); nb.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("expected 5 models, got %v", nb)
+	}
+}
+
+func TestCountFile(t *testing.T) {
+	f, err := os.Open("testcnf/25.cnf")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer func() { _ = f.Close() }()
+	pb, err := ParseCNF(f)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if nb := New(pb).Count(); nb.Cmp(big.NewInt(66)) != 0 {
+		t.Errorf("expected 66 models, got %v", nb)
+	}
+}
+
+func TestCountLarge(t *testing.T) {
+	// 100 vars, only 2 of which are constrained: the result does not fit in an int.
+	s := New(ParseSliceNb([][]int{{1, 2}}, 100))
+	expected := new(big.Int).Lsh(big.NewInt(3), 98)
+	if nb := s.Count(); nb.Cmp(expected) != 0 {
+		t.Errorf("expected %v models, got %v", expected, nb)
+	}
+	s = New(ParseSlice([][]int{{1}, {-1}}))
+	if nb := s.Count(); nb.Sign() != 0 {
+		t.Errorf("expected 0 models, got %v", nb)
+	}
+}
+
+func TestWeightedCount(t *testing.T) {
+	const cnf = `c A simple weighted problem
+c p weight 1 0.3 0
+c p weight -1 0.7 0
+c p weight 2 3/5 0
+c p weight -2 0.4 0
+c p weight 3 0.2 0
+c p weight 4 0.5 0
+c p weight -4 0.5 0
+p cnf 4 2
+1 2 0
+3 0
+`
+	pb, weights, err := ParseWeightedCNF(strings.NewReader(cnf))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	s := New(pb)
+	// P(1 or 2) * P(3) = (1 - 0.7*0.4) * 0.2
+	expected := big.NewRat(18, 125)
+	if wmc := s.WeightedCountRat(weights); wmc.Cmp(expected) != 0 {
+		t.Errorf("invalid weighted count: expected %v, got %v", expected, wmc)
+	}
+	if wmc := s.WeightedCount(FloatWeights(weights)); math.Abs(wmc-0.144) > 1e-9 {
+		t.Errorf("invalid float weighted count: expected 0.144, got %v", wmc)
+	}
+	if _, _, err := ParseWeightedCNF(strings.NewReader("c p weight 5 0.5 0\np cnf 1 1\n1 0\n")); err == nil {
+		t.Errorf("expected error for weight of unknown var")
+	}
+}
+
+func TestApproxCount(t *testing.T) {
+	s := New(ParseSliceNb([][]int{{1, 2}}, 3))
+	if est := s.ApproxCount(nil, 0.8, 0.2, 1); !est.Exact || est.Count.Cmp(big.NewInt(6)) != 0 {
+		t.Errorf("expected exact count of 6, got %v (exact: %t)", est.Count, est.Exact)
+	}
+	if est := s.ApproxCount([]Var{0, 1}, 0.8, 0.2, 1); !est.Exact || est.Count.Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("expected exact projected count of 3, got %v (exact: %t)", est.Count, est.Exact)
+	}
+	s = New(ParseSliceNb([][]int{{1, 2}, {3, 4}, {-5, -6, 7}}, 16))
+	expected := s.Count()
+	est := s.ApproxCount(nil, 0.8, 0.2, 1)
+	if est.Exact {
+		t.Errorf("expected approximate count, got exact count %v", est.Count)
+	}
+	if est.Lower.Cmp(expected) > 0 || est.Upper.Cmp(expected) < 0 {
+		t.Errorf("expected count %v to be between %v and %v", expected, est.Lower, est.Upper)
+	}
+	if s.Solve() != Sat {
+		t.Errorf("solver should still be usable after approximate counting")
+	}
+}
+
+func TestSample(t *testing.T) {
+	s := New(ParseSlice([][]int{{1, 2}}))
+	const n = 3000
+	samples := s.Sample(n, nil, 1)
+	if len(samples) != n {
+		t.Fatalf("expected %d samples, got %d", n, len(samples))
+	}
+	freq := make(map[[2]bool]int)
+	for _, model := range samples {
+		freq[[2]bool{model[0], model[1]}]++
+	}
+	if len(freq) != 3 || freq[[2]bool{false, false}] != 0 {
+		t.Errorf("invalid models sampled: %v", freq)
+	}
+	for model, nb := range freq {
+		if nb < n/4 || nb > n/2 {
+			t.Errorf("model %v was sampled %d times out of %d", model, nb, n)
+		}
+	}
+	s = New(ParseSliceNb([][]int{{1, 2}, {3, 4}, {-5, -6, 7}}, 16))
+	samples = s.Sample(10, nil, 1)
+	if len(samples) != 10 {
+		t.Fatalf("expected %d samples, got %d", 10, len(samples))
+	}
+	for _, model := range samples {
+		if !(model[0] || model[1]) || !(model[2] || model[3]) || !(!model[4] || !model[5] || model[6]) {
+			t.Errorf("sampled model %v is not a model", model)
+		}
+	}
+	if samples := New(ParseSlice([][]int{{1}, {-1}})).Sample(10, nil, 1); samples != nil {
+		t.Errorf("expected no sample for UNSAT problem, got %v", samples)
+	}
+}
+
+func TestXorCellsVars(t *testing.T) {
+	const n = 16
+	s := New(ParseSliceNb([][]int{{1, 2}, {3, 4}, {-5, -6, 7}}, n))
+	sampling := make([]Var, n)
+	for i := range sampling {
+		sampling[i] = Var(i)
+	}
+	s2 := s.clone()
+	xors := &xorCells{s: s2}
+	rng := rand.New(rand.NewSource(1))
+	s2.approxCount(sampling, 0.8, 0.2, xors, rng)
+	for i := 0; i < 50; i++ {
+		s2.sampleCell(sampling, 12, xors, rng)
+	}
+	// At most n XORs are active at once, each with a selector and at most n-1 auxiliary vars,
+	// plus the selector of the blocking clauses and the selectors that were not recycled yet.
+	if maxVars := n + n + n*(n-1) + 1 + cleanupPeriod; s2.nbVars > maxVars {
+		t.Errorf("expected at most %d vars, got %d", maxVars, s2.nbVars)
+	}
+}
+
+func TestProjected(t *testing.T) {
+	const cnf = `c 3 is an auxiliary var: 3 <=> (1 and 2)
+c ind 1 2 0
+c p show 4 0
+p cnf 4 4
+-3 1 0
+-3 2 0
+3 -1 -2 0
+3 4 0
+`
+	pb, vars, err := ParseProjectedCNF(strings.NewReader(cnf))
+	if err != nil {
+		t.Fatalf("could not parse problem: %v", err)
+	}
+	if len(vars) != 3 || vars[0] != 0 || vars[1] != 1 || vars[2] != 3 {
+		t.Errorf("invalid sampling set %v", vars)
+	}
+	s := New(pb)
+	// 4 or (1 and 2): 5 models over vars 1, 2 and 4
+	if nb := s.CountProjected(vars); nb.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("expected 5 projected models, got %v", nb)
+	}
+	if nb := s.CountProjected(vars[:2]); nb.Cmp(big.NewInt(4)) != 0 {
+		t.Errorf("expected 4 projected models, got %v", nb)
+	}
+	models := make(chan []bool)
+	go s.EnumerateProjected(vars, models, nil)
+	nb := 0
+	for model := range models {
+		nb++
+		if !model[3] && !(model[0] && model[1]) {
+			t.Errorf("invalid model %v", model)
+		}
+	}
+	if nb != 5 {
+		t.Errorf("expected 5 projected models, got %d", nb)
+	}
+	if nb := s.EnumerateProjected([]Var{vars[0], vars[1], vars[0], vars[2], vars[2]}, nil, nil); nb != 5 {
+		t.Errorf("expected 5 projected models with duplicate vars, got %d", nb)
+	}
+	if nb := s.Count(); nb.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("expected 5 models, got %v", nb)
+	}
+	if _, _, err := ParseProjectedCNF(strings.NewReader("c ind 3 0\np cnf 2 1\n1 2 0\n")); err == nil {
+		t.Errorf("expected error for unknown var in sampling set")
+	}
+}
+
+func TestEnumerateCubes(t *testing.T) {
+	// Constraints are built for each solver, since parsing can modify their weights.
+	newSolver := func() *Solver {
+		pb1 := AtMost([]int{1, 2, 3, 4}, 3)
+		pb2 := AtLeast([]int{1, 2, 3, 4}, 2)
+		pb3 := GtEq([]int{2, 3, 4}, []int{1, 1, 2}, 3)
+		pb4 := AtLeast([]int{5, 6}, 1)
+		return New(ParsePBConstrs([]PBConstr{pb1, pb2, pb3, pb4}))
+	}
+	for _, prime := range []bool{false, true} {
+		s := newSolver()
+		cubes := make(chan []Lit)
+		var all [][]Lit
+		done := make(chan struct{})
+		go func() {
+			for cube := range cubes {
+				all = append(all, cube)
+			}
+			close(done)
+		}()
+		nb := s.EnumerateCubes(cubes, prime, nil)
+		<-done
+		if nb != len(all) {
+			t.Errorf("prime=%t: %d cubes returned, %d cubes sent", prime, nb, len(all))
+		}
+		// Check each cube is an implicant, and that each model extends a cube.
+		covered := make([]int, 1<<6)
+		for _, cube := range all {
+			sub := newSolver()
+			sub.Assume(cube)
+			if nbCube := sub.Count(); nbCube.Cmp(big.NewInt(int64(1<<uint(6-len(cube))))) != 0 {
+				t.Errorf("prime=%t: cube %v is not an implicant", prime, cube)
+			}
+			for i := range cube {
+				if !prime {
+					break
+				}
+				smaller := append(append([]Lit{}, cube[:i]...), cube[i+1:]...)
+				sub.Assume(smaller)
+				if nbCube := sub.Count(); nbCube.Cmp(big.NewInt(int64(1<<uint(7-len(cube))))) == 0 {
+					t.Errorf("cube %v is not prime: %v is an implicant", cube, smaller)
+				}
+			}
+			for m := range covered {
+				ok := true
+				for _, lit := range cube {
+					if (m&(1<<uint(lit.Var())) != 0) == lit.IsPositive() {
+						continue
+					}
+					ok = false
+				}
+				if ok {
+					covered[m]++
+				}
+			}
+		}
+		nbModels := 0
+		for _, n := range covered {
+			if n > 0 {
+				nbModels++
+			}
+			if !prime && n > 1 {
+				t.Errorf("cubes overlap")
+			}
+		}
+		if nbModels != 15 {
+			t.Errorf("prime=%t: cubes cover %d models, expected 15", prime, nbModels)
+		}
+		if s.Solve() != Sat {
+			t.Errorf("prime=%t: solver unusable after enumeration", prime)
+		}
+	}
+}
+
+func TestEnumerateCubesBruteForce(t *testing.T) {
+	// x1 and x3 are units, so they are simplified away from blocking clauses.
+	clauses := [][]int{{-1}, {3}, {-5, -6}, {2, 4, -6}}
+	const nbVars = 6
+	for _, prime := range []bool{false, true} {
+		cubes := make(chan []Lit, 1<<nbVars)
+		New(ParseSlice(clauses)).EnumerateCubes(cubes, prime, nil)
+		var all [][]Lit
+		for cube := range cubes {
+			all = append(all, cube)
+		}
+		for m := 0; m < 1<<nbVars; m++ {
+			binding := func(lit int) bool {
+				if lit < 0 {
+					return m&(1<<uint(-lit-1)) == 0
+				}
+				return m&(1<<uint(lit-1)) != 0
+			}
+			isModel := true
+			for _, clause := range clauses {
+				sat := false
+				for _, lit := range clause {
+					sat = sat || binding(lit)
+				}
+				isModel = isModel && sat
+			}
+			nbCubes := 0
+			for _, cube := range all {
+				inCube := true
+				for _, lit := range cube {
+					inCube = inCube && binding(int(lit.Int()))
+				}
+				if inCube {
+					nbCubes++
+				}
+			}
+			switch {
+			case !isModel && nbCubes > 0:
+				t.Errorf("prime=%t: assignment %b is not a model, but is in %d cubes", prime, m, nbCubes)
+			case isModel && nbCubes == 0:
+				t.Errorf("prime=%t: model %b is not in any cube", prime, m)
+			case !prime && nbCubes > 1:
+				t.Errorf("model %b is in %d overlapping cubes", m, nbCubes)
+			}
+		}
+	}
+}
+
+func TestEnumerateCubesManyVars(t *testing.T) {
+	pb := ParseSliceNb([][]int{{1, 2}, {-1, -2, 70}}, 70)
+	cubes := make(chan []Lit, 10)
+	if nb := New(pb).EnumerateCubes(cubes, false, nil); nb != 3 {
+		t.Errorf("expected 3 cubes, got %d", nb)
+	}
+	total := new(big.Int)
+	for cube := range cubes {
+		total.Add(total, new(big.Int).Lsh(bigOne, uint(70-len(cube))))
+	}
+	if nb := New(pb).Count(); total.Cmp(nb) != 0 {
+		t.Errorf("cubes cover %v models, expected %v", total, nb)
+	}
+}
+
+func TestEnumerateCubesTwice(t *testing.T) {
+	s := New(ParseSlice([][]int{{1, 2}, {-1, 3}}))
+	for call := 0; call < 3; call++ {
+		for _, prime := range []bool{false, true} {
+			cubes := make(chan []Lit, 10)
+			s.EnumerateCubes(cubes, prime, nil)
+			total := 0
+			for cube := range cubes {
+				for _, lit := range cube {
+					if lit.Var() >= 3 {
+						t.Errorf("call #%d: cube %v contains an unknown var", call, cube)
+					}
+				}
+				total += 1 << uint(3-len(cube))
+			}
+			if !prime && total != 4 {
+				t.Errorf("call #%d: cubes cover %d models, expected 4", call, total)
+			}
+		}
+	}
+	s.Solve()
+	if imp := s.Implicant(nil); len(imp) != 2 {
+		t.Errorf("expected an implicant with 2 lits, got %v", imp)
+	}
+}
@@ -0,0 +1,52 @@
+package solver
+
+// EnumerateCubes enumerates the models of the problem as cubes, i.e partial assignments whose extensions are all models.
+// Each cube is written on cubes as a list of lits, sorted by var: vars that do not appear in a cube are don't-cares.
+// Top-level bindings, such as unit clauses of the problem, appear in every cube.
+// Each model of the problem is an extension of at least one cube.
+// If prime is false, cubes are pairwise disjoint, so that the number of models is the sum, over all cubes,
+// of 2^(number of vars - size of the cube).
+// If prime is true, each cube is a prime implicant of the problem, i.e no lit can be removed from it,
+// but cubes can overlap.
+// If stop is non-nil, enumeration stops as soon as a value is received or stop is closed.
+// cubes will be closed at the end of the method. The method returns the number of cubes found.
+// Current assumptions, if any, are discarded, but the solver can be used as usual afterwards.
+func (s *Solver) EnumerateCubes(cubes chan []Lit, prime bool, stop chan struct{}) int {
+	if cubes != nil {
+		defer close(cubes)
+	}
+	if s.trivialUnsat {
+		return 0
+	}
+	sh := newShrinker(s.wl.origClauses, s.units, s.nbUserVars)
+	block := s.newSelector() // Blocking clauses are only active when block is true
+	nb := 0
+	for {
+		select {
+		case <-stop:
+			s.disableSelector(block)
+			return nb
+		default:
+		}
+		if s.Assume([]Lit{block}) == Unsat || s.Solve() != Sat {
+			break
+		}
+		cube := sh.shrink(s.model, nil)
+		nb++
+		if cubes != nil {
+			cubes <- cube
+		}
+		lits := make([]Lit, len(cube)+1)
+		for i, lit := range cube {
+			lits[i] = lit.Negation()
+		}
+		lits[len(cube)] = block.Negation()
+		// AppendClause can simplify the lits of the clause in place, so it gets its own copy
+		s.AppendClause(NewClause(append([]Lit{}, lits...)))
+		if !prime { // Next cubes must not overlap with this one
+			sh.addConstr(lits[:len(cube)], nil, 1)
+		}
+	}
+	s.disableSelector(block)
+	return nb
+}
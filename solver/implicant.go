@@ -0,0 +1,160 @@
+package solver
+
+import "sort"
+
+// Implicant reduces the last model found by the solver to a minimal partial assignment that still satisfies
+// all the constraints of the problem, including cardinality and pseudo-boolean constraints, and returns it as a list of lits sorted by var.
+// The result is a prime implicant of the problem: all extensions of the returned lits are models,
+// and no lit can be removed from it without breaking that property. It is minimal with respect to inclusion,
+// but it is not necessarily the smallest implicant of the problem.
+// If important is not nil, only the vars it contains can be removed from the assignment:
+// the other vars keep their binding from the model, but they do not appear in the result.
+// The result is then a prime implicant of the problem in which those other vars are bound as in the model.
+// This is typically useful when the problem contains auxiliary variables that are not meaningful to the user.
+// Current assumptions are not considered as constraints, so they do not necessarily appear in the result.
+// If s's status is not Sat, the method will panic.
+func (s *Solver) Implicant(important []Var) []Lit {
+	if s.lastModel == nil {
+		panic("cannot call Implicant() from a non-Sat solver")
+	}
+	nbVars := s.nbUserVars
+	var imp []bool
+	if important != nil {
+		imp = make([]bool, nbVars)
+		for _, v := range important {
+			imp[v] = true
+		}
+	}
+	sh := newShrinker(s.wl.origClauses, s.units, nbVars)
+	return sh.shrink(s.lastModel, imp)
+}
+
+// A shrinker reduces models to prime implicants of a set of constraints.
+type shrinker struct {
+	nbVars  int
+	constrs []cntConstr    // Constraints that must be satisfied by the implicant. Only lits, weights and card are used.
+	occurs  [][]occurrence // For each lit, the constraints it appears in
+	units   []Lit          // Lits that are part of all implicants
+}
+
+// newShrinker returns a shrinker for the given constraints and units, restricted to the first nbVars vars.
+// Constraints that are satisfied by the units, such as constraints guarded by a disabled selector, are ignored.
+func newShrinker(clauses []*Clause, units []Lit, nbVars int) *shrinker {
+	sh := &shrinker{nbVars: nbVars, occurs: make([][]occurrence, 2*nbVars)}
+	met := make([]bool, nbVars)
+	isUnit := make(map[Lit]bool, len(units))
+	for _, unit := range units {
+		isUnit[unit] = true
+		if v := unit.Var(); int(v) < nbVars && !met[v] {
+			met[v] = true
+			sh.units = append(sh.units, unit)
+		}
+	}
+	for _, c := range clauses {
+		sum := 0
+		for i := 0; i < c.Len(); i++ {
+			if isUnit[c.Get(i)] {
+				sum += c.Weight(i)
+			}
+		}
+		if sum >= c.Cardinality() {
+			continue
+		}
+		lits := make([]Lit, c.Len())
+		copy(lits, c.lits)
+		var weights []int
+		if c.PseudoBoolean() {
+			weights = make([]int, c.Len())
+			copy(weights, c.pbData.weights)
+		}
+		sh.addConstr(lits, weights, c.Cardinality())
+	}
+	return sh
+}
+
+// addConstr adds a constraint that must be satisfied by all implicants.
+func (sh *shrinker) addConstr(lits []Lit, weights []int, card int) {
+	idx := len(sh.constrs)
+	constr := cntConstr{lits: lits, weights: weights, card: card}
+	for i, lit := range lits {
+		if int(lit.Var()) < sh.nbVars {
+			sh.occurs[lit] = append(sh.occurs[lit], occurrence{constr: idx, weight: constr.weight(i)})
+		}
+	}
+	sh.constrs = append(sh.constrs, constr)
+}
+
+// shrink returns a prime implicant of the constraints that is included in the given model, sorted by var.
+// The model must satisfy all the constraints.
+// If important is not nil, only vars v such that important[v] is true can be removed from the implicant:
+// other vars keep their binding, but they are not part of the returned lits.
+func (sh *shrinker) shrink(model Model, important []bool) []Lit {
+	inCube := make([]bool, sh.nbVars)
+	isFixed := make([]bool, sh.nbVars)   // Vars that cannot be removed from the implicant
+	sums := make([]int, len(sh.constrs)) // For each constraint, sum of the weights of the lits in the cube
+	add := func(lit Lit) {
+		inCube[lit.Var()] = true
+		for _, occ := range sh.occurs[lit] {
+			sums[occ.constr] += occ.weight
+		}
+	}
+	trueLit := func(v Var) Lit { return v.SignedLit(model[v] < 0) }
+	for _, unit := range sh.units {
+		isFixed[unit.Var()] = true
+		add(unit)
+	}
+	for v := range important {
+		if !important[v] && !isFixed[v] {
+			isFixed[v] = true
+			add(trueLit(Var(v)))
+		}
+	}
+	// First, greedily cover each constraint with the lits of the model, heaviest lits first.
+	var cands []int // Indices of candidate lits in the current constraint
+	for i := range sh.constrs {
+		constr := &sh.constrs[i]
+		if sums[i] >= constr.card {
+			continue
+		}
+		cands = cands[:0]
+		for j, lit := range constr.lits {
+			if v := lit.Var(); int(v) < sh.nbVars && !inCube[v] && trueLit(v) == lit {
+				cands = append(cands, j)
+			}
+		}
+		sort.SliceStable(cands, func(a, b int) bool { return constr.weight(cands[a]) > constr.weight(cands[b]) })
+		for _, j := range cands {
+			if sums[i] >= constr.card {
+				break
+			}
+			add(constr.lits[j])
+		}
+	}
+	// Then, remove lits that are not necessary.
+	for v := 0; v < sh.nbVars; v++ {
+		if !inCube[v] || isFixed[v] {
+			continue
+		}
+		lit := trueLit(Var(v))
+		necessary := false
+		for _, occ := range sh.occurs[lit] {
+			if sums[occ.constr]-occ.weight < sh.constrs[occ.constr].card {
+				necessary = true
+				break
+			}
+		}
+		if !necessary {
+			inCube[v] = false
+			for _, occ := range sh.occurs[lit] {
+				sums[occ.constr] -= occ.weight
+			}
+		}
+	}
+	var cube []Lit
+	for v := 0; v < sh.nbVars; v++ {
+		if inCube[v] && (important == nil || important[v]) {
+			cube = append(cube, trueLit(Var(v)))
+		}
+	}
+	return cube
+}
@@ -0,0 +1,274 @@
+package solver
+
+import "fmt"
+
+// An InterpolationSystem tells how partial interpolants are computed along a resolution refutation.
+type InterpolationSystem int
+
+const (
+	// McMillan is McMillan's interpolation system. It yields interpolants that are stronger than Pudlák's ones.
+	McMillan InterpolationSystem = iota
+	// Pudlak is Pudlák's symmetric interpolation system, also known as HKP.
+	Pudlak
+)
+
+// A GateOp is the operator of a Gate.
+type GateOp int
+
+// Possible operators of a gate.
+const (
+	GateFalse GateOp = iota // Constant false; the gate has no input.
+	GateTrue                // Constant true; the gate has no input.
+	GateLit                 // The gate is a literal, given by Lit; it has no input.
+	GateAnd                 // Conjunction of the two inputs.
+	GateOr                  // Disjunction of the two inputs.
+)
+
+// A Gate is a node of a boolean circuit. Since inputs can be shared between several gates,
+// the circuit is a DAG rather than a tree.
+type Gate struct {
+	Op     GateOp
+	Lit    Lit      // The literal, if Op is GateLit
+	Inputs [2]*Gate // The inputs, if Op is GateAnd or GateOr
+}
+
+var (
+	gateFalse = &Gate{Op: GateFalse}
+	gateTrue  = &Gate{Op: GateTrue}
+)
+
+// String returns a representation of the circuit, where vars are displayed as DIMACS ints.
+func (g *Gate) String() string {
+	switch g.Op {
+	case GateFalse:
+		return "⊥"
+	case GateTrue:
+		return "⊤"
+	case GateLit:
+		return fmt.Sprintf("%d", g.Lit.Int())
+	case GateAnd:
+		return fmt.Sprintf("(%v ∧ %v)", g.Inputs[0], g.Inputs[1])
+	default:
+		return fmt.Sprintf("(%v ∨ %v)", g.Inputs[0], g.Inputs[1])
+	}
+}
+
+// andGate returns the conjunction of g1 and g2, simplified if one of them is constant.
+func andGate(g1, g2 *Gate) *Gate {
+	switch {
+	case g1.Op == GateFalse || g2.Op == GateTrue || g1 == g2:
+		return g1
+	case g2.Op == GateFalse || g1.Op == GateTrue:
+		return g2
+	}
+	return &Gate{Op: GateAnd, Inputs: [2]*Gate{g1, g2}}
+}
+
+// orGate returns the disjunction of g1 and g2, simplified if one of them is constant.
+func orGate(g1, g2 *Gate) *Gate {
+	switch {
+	case g1.Op == GateTrue || g2.Op == GateFalse || g1 == g2:
+		return g1
+	case g2.Op == GateTrue || g1.Op == GateFalse:
+		return g2
+	}
+	return &Gate{Op: GateOr, Inputs: [2]*Gate{g1, g2}}
+}
+
+// The locality of a var with respect to an A/B partition.
+const (
+	localA = 1 << iota // The var appears in A
+	localB             // The var appears in B
+	shared = localA | localB
+)
+
+// An interpolation records, for each clause of a refutation, its partial interpolant.
+type interpolation struct {
+	system  InterpolationSystem
+	class   []int             // Locality of each var; guards are local to the side of their clause
+	gates   map[*Clause]*Gate // Partial interpolant of each clause
+	learned *Gate             // Partial interpolant of the last learned clause or unit
+	result  *Gate             // The interpolant, once the refutation is over
+	err     error             // Non-nil iff the refutation could not be interpolated
+}
+
+// resolve returns the partial interpolant of the resolvent of two clauses, on pivot v,
+// given the partial interpolants of the clause that contains v positively and of the one that contains it negatively.
+func (itp *interpolation) resolve(pos, neg *Gate, v Var) *Gate {
+	switch itp.class[v] {
+	case localA:
+		return orGate(pos, neg)
+	case localB:
+		return andGate(pos, neg)
+	}
+	if itp.system == McMillan {
+		return andGate(pos, neg)
+	}
+	x := &Gate{Op: GateLit, Lit: v.Lit()}
+	notX := &Gate{Op: GateLit, Lit: v.Lit().Negation()}
+	return andGate(orGate(x, pos), orGate(notX, neg))
+}
+
+// resolveReason returns the partial interpolant of the resolvent of the clause whose partial interpolant is cur
+// with the reason of v, v being bound. v must appear in both clauses.
+func (s *Solver) resolveReason(cur *Gate, v Var) *Gate {
+	itp := s.itp
+	reason, ok := itp.gates[s.reason[v]]
+	if !ok {
+		itp.err = fmt.Errorf("clause %v was not part of the refutation", s.reason[v])
+		return cur
+	}
+	if s.model[v] > 0 { // Reason contains v, the current clause contains its negation
+		return itp.resolve(reason, cur, v)
+	}
+	return itp.resolve(cur, reason, v)
+}
+
+// finishInterpolant computes the interpolant of a top-level conflict, i.e when the clause c, whose lits
+// are given, is false at level 1. If c is nil, it is the last learned clause.
+// All the bound vars of the clause are resolved in reverse trail order, until only assumptions remain.
+func (s *Solver) finishInterpolant(c *Clause, lits []Lit) {
+	cur := s.itp.learned
+	if c != nil {
+		var ok bool
+		if cur, ok = s.itp.gates[c]; !ok {
+			s.itp.err = fmt.Errorf("conflict clause %v was not part of the refutation", c)
+			return
+		}
+	}
+	inClause := make([]bool, s.nbVars)
+	for _, lit := range lits {
+		inClause[lit.Var()] = true
+	}
+	for i := len(s.trail) - 1; i >= 0; i-- {
+		v := s.trail[i].Var()
+		if !inClause[v] || s.assumptions[v] {
+			continue
+		}
+		if s.reason[v] == nil {
+			s.itp.err = fmt.Errorf("var %d is neither implied nor assumed", v.Int())
+			return
+		}
+		cur = s.resolveReason(cur, v)
+		reason := s.reason[v]
+		for j := 0; j < reason.Len(); j++ {
+			inClause[reason.Get(j).Var()] = true
+		}
+	}
+	s.itp.result = cur
+}
+
+// Interpolant returns a Craig interpolant for the UNSAT problem made of the clauses in a and b,
+// i.e a formula I that only contains vars that appear in both a and b, such that a implies I and
+// the conjunction of I and b is UNSAT.
+// Clauses are given as DIMACS ints. The interpolant is returned as a boolean circuit over DIMACS vars.
+// It is computed from a refutation of the problem, built from the resolution chains that
+// are recorded during conflict analysis, using the given interpolation system.
+// To that end, each clause is guarded by a selector var that is assumed, so that all top-level
+// bindings are explained by a clause.
+// If the problem is satisfiable, the function returns an error.
+func Interpolant(a, b [][]int, system InterpolationSystem) (*Gate, error) {
+	if isEmptyIn(a) { // a is UNSAT by itself
+		return gateFalse, nil
+	}
+	if isEmptyIn(b) { // b is UNSAT by itself
+		return gateTrue, nil
+	}
+	clauses := append(append([][]int{}, a...), b...)
+	nbVars := 0
+	for _, clause := range clauses {
+		for _, lit := range clause {
+			if lit > nbVars {
+				nbVars = lit
+			} else if -lit > nbVars {
+				nbVars = -lit
+			}
+		}
+	}
+	nbClauses := len(a) + len(b)
+	itp := &interpolation{
+		system: system,
+		class:  make([]int, nbVars+nbClauses),
+		gates:  make(map[*Clause]*Gate, nbClauses),
+	}
+	for i, clause := range a {
+		for _, lit := range clause {
+			itp.class[IntToLit(int32(lit)).Var()] |= localA
+		}
+		itp.class[nbVars+i] = localA
+	}
+	for i, clause := range b {
+		for _, lit := range clause {
+			itp.class[IntToLit(int32(lit)).Var()] |= localB
+		}
+		itp.class[nbVars+len(a)+i] = localB
+	}
+	pb := &Problem{NbVars: nbVars + nbClauses, Model: make([]decLevel, nbVars+nbClauses)}
+	assumptions := make([]Lit, 0, nbClauses)
+	for i, clause := range clauses {
+		guard := Var(nbVars + i).Lit()
+		assumptions = append(assumptions, guard)
+		lits := []Lit{guard.Negation()}
+		tautology := false
+		for _, val := range clause {
+			lit := IntToLit(int32(val))
+			for _, l := range lits {
+				tautology = tautology || l == lit.Negation()
+			}
+			if !containsLit(lits, lit) {
+				lits = append(lits, lit)
+			}
+		}
+		if tautology { // Can never be part of a refutation
+			continue
+		}
+		c := NewClause(lits)
+		pb.Clauses = append(pb.Clauses, c)
+		switch {
+		case i >= len(a):
+			itp.gates[c] = gateTrue
+		case system == Pudlak:
+			itp.gates[c] = gateFalse
+		default: // McMillan: disjunction of the shared lits of the clause
+			g := gateFalse
+			for _, lit := range lits {
+				if itp.class[lit.Var()] == shared {
+					g = orGate(g, &Gate{Op: GateLit, Lit: lit})
+				}
+			}
+			itp.gates[c] = g
+		}
+	}
+	s := New(pb)
+	s.itp = itp
+	if s.Assume(assumptions) != Unsat && s.Solve() != Unsat {
+		return nil, fmt.Errorf("problem is not UNSAT")
+	}
+	if itp.err != nil {
+		return nil, fmt.Errorf("could not compute interpolant: %v", itp.err)
+	}
+	if itp.result == nil {
+		return nil, fmt.Errorf("could not compute interpolant: no refutation was recorded")
+	}
+	return itp.result, nil
+}
+
+// isEmptyIn returns true iff clauses contains the empty clause.
+func isEmptyIn(clauses [][]int) bool {
+	for _, clause := range clauses {
+		if len(clause) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// containsLit returns true iff lit is in lits.
+func containsLit(lits []Lit, lit Lit) bool {
+	for _, l := range lits {
+		if l == lit {
+			return true
+		}
+	}
+	return false
+}
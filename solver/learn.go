@@ -1,5 +1,7 @@
 package solver
 
+import "fmt"
+
 // computeLbd computes and sets c's LBD (Literal Block Distance).
 func (c *Clause) computeLbd(model Model) {
 	c.setLbd(1)
@@ -36,20 +38,25 @@ func (s *Solver) addClauseLits(confl *Clause, lvl decLevel, met, metLvl []bool,
 	return nbLvl
 }
 
-var bufLits = make([]Lit, 10_000) // Buffer for lits in learnClause. Used to reduce allocations.
-
 // learnClause creates a conflict clause and returns either:
 // - the clause itself, if its len is at least 2,
 // - a nil clause and a unit literal, if its len is exactly 1,
 // - a nil clause and -1, if the empty clause was learned.
 func (s *Solver) learnClause(confl *Clause, lvl decLevel) (learned *Clause, unit Lit) {
 	s.clauseBumpActivity(confl)
-	lits := bufLits[:1]             // Not 0: make room for asserting literal
+	lits := s.litsBuf[:1]           // Not 0: make room for asserting literal
 	buf := make([]bool, s.nbVars*2) // Buffer for met and metLvl; reduces allocs/deallocs
 	met := buf[:s.nbVars]           // List of all vars already met
 	metLvl := buf[s.nbVars:]        // List of all vars from current level to deal with
 	// nbLvl is the nb of vars in lvl currently used
 	nbLvl := s.addClauseLits(confl, lvl, met, metLvl, &lits)
+	var itp *Gate // Partial interpolant of the current resolvent, if an interpolant is being computed
+	if s.itp != nil {
+		var ok bool
+		if itp, ok = s.itp.gates[confl]; !ok {
+			s.itp.err = fmt.Errorf("conflict clause %v was not part of the refutation", confl)
+		}
+	}
 	ptr := len(s.trail) - 1 // Pointer in propagation trail
 	for nbLvl > 1 {         // We will stop once we only have one lit from current level.
 		for !metLvl[s.trail[ptr].Var()] {
@@ -66,6 +73,9 @@ func (s *Solver) learnClause(confl *Clause, lvl decLevel) (learned *Clause, unit
 		ptr--
 		nbLvl--
 		if reason := s.reason[v]; reason != nil {
+			if s.itp != nil {
+				itp = s.resolveReason(itp, v)
+			}
 			s.clauseBumpActivity(reason)
 			for i := 0; i < reason.Len(); i++ {
 				lit := reason.Get(i)
@@ -94,7 +104,12 @@ func (s *Solver) learnClause(confl *Clause, lvl decLevel) (learned *Clause, unit
 	s.varDecayActivity()
 	s.clauseDecayActivity()
 	sortLiterals(lits, s.model)
-	sz := s.minimizeLearned(met, lits)
+	sz := len(lits)
+	if s.itp == nil {
+		sz = s.minimizeLearned(met, lits)
+	} else { // Minimization is not recorded as resolution steps
+		s.itp.learned = itp
+	}
 	if sz == 1 {
 		// fmt.Printf("learned unit %d, trail is %s\n", lits[0].Int(), s.trailString())
 		return nil, lits[0]
@@ -103,6 +118,9 @@ func (s *Solver) learnClause(confl *Clause, lvl decLevel) (learned *Clause, unit
 	copy(lits2, lits[:sz])
 	learned = NewLearnedClause(lits2)
 	learned.computeLbd(s.model)
+	if s.itp != nil {
+		s.itp.gates[learned] = itp
+	}
 	// fmt.Printf("learned clause %s, trail is %s\n", learned.CNF(), s.trailString())
 	return learned, -1
 }
@@ -1,5 +1,7 @@
 package solver
 
+import "strconv"
+
 // a pbSet is a set representation of a PB constraint.
 // It indicates, for each variable in the problem, what its weight is in the constraint.
 // For instance, in a problem with 5 vars, the lits in constraint 5 x1 +3 ~x2 +2 x4+ x5 >= 6 will be encoded as
@@ -7,6 +9,9 @@ package solver
 type pbSet struct {
 	weights []int // The weight of each variable in the constraint, or 0 if the var isn't in the constraint.
 	card    int
+	// If a proof is being logged, the derivation of the constraint, in reverse polish notation,
+	// or nil if it cannot be traced back to the formula.
+	expr []string
 }
 
 // pbSet converts c to the psSet structure.
@@ -25,6 +30,11 @@ func (s *Solver) pbSet(c *Clause, buffer []int) *pbSet {
 		}
 		res.weights[v] = w
 	}
+	if s.proof != nil {
+		if id, ok := s.proof.ids[c]; ok {
+			res.expr = []string{strconv.Itoa(id)}
+		}
+	}
 	return res
 }
 
@@ -60,6 +70,11 @@ func (pb1 *pbSet) clash(s *Solver, pb2 *pbSet) {
 			pb1.card -= min(abs(w1), abs(w2))
 		}
 	}
+	if pb1.expr != nil && pb2.expr != nil {
+		pb1.expr = append(append(pb1.expr, pb2.expr...), "+")
+	} else { // If one of them cannot be traced, neither can the sum
+		pb1.expr = nil
+	}
 }
 
 // slack returns the slack of pb1 for the given decision level.
@@ -112,9 +127,13 @@ func (s *Solver) cuttingPlanes(confl *Clause, lvl decLevel) (learned *Clause, pr
 	ptr := len(s.trail) - 1
 	for pb.onlyFalsified(s, ptr, lvl) < 0 {
 		if lvl == 1 { // Top-level conflict: UNSAT
+			if pb.expr != nil {
+				s.proofDerive(pb.expr)
+			}
 			return nil, nil, -1
 		}
 		lit := s.trail[ptr]
+		prevLvl := lvl
 		for !pb.falsifies(lit) {
 			if s.reason[lit.Var()] == nil {
 				lvl--
@@ -124,6 +143,9 @@ func (s *Solver) cuttingPlanes(confl *Clause, lvl decLevel) (learned *Clause, pr
 			ptr--
 			lit = s.trail[ptr]
 		}
+		if lvl != prevLvl { // pb might be asserting at this lower level
+			continue
+		}
 		v := lit.Var()
 		s.varBumpActivity(v) // RoundingSAT's strategy: eliminated variables are bumped twice
 		pb.roundToOne(s, v, lvl)
@@ -150,7 +172,15 @@ func (s *Solver) cuttingPlanes(confl *Clause, lvl decLevel) (learned *Clause, pr
 	}
 	// s.varDecayActivity()
 	// s.clauseDecayActivity()
-	if propagated, learned, ok := pb.clause().SimplifyPB(); !ok {
+	propagated, learned, ok := pb.clause().SimplifyPB()
+	if pb.expr != nil {
+		if ok && len(propagated) == 0 { // learned is pb, saturated
+			s.proof.ids[learned] = s.proofDerive(append(pb.expr, "s"))
+		} else { // Units, if any, will be checked by unit propagation on pb
+			s.proofDerive(pb.expr)
+		}
+	}
+	if !ok {
 		return nil, nil, -1
 	} else if len(propagated) > 0 {
 		return nil, propagated, 1
@@ -209,9 +239,15 @@ func (pb *pbSet) roundToOne(s *Solver, locked Var, lvl decLevel) {
 			// lit j isn't falsified: weaken constraint by removing it
 			pb.weights[j] = 0
 			pb.card -= abs(wj)
+			if pb.expr != nil {
+				pb.expr = append(pb.expr, proofVar(Var(j)), "w")
+			}
 		}
 	}
 	pb.divideBy(wi)
+	if pb.expr != nil {
+		pb.expr = append(pb.expr, strconv.Itoa(wi), "d")
+	}
 }
 
 // divideBy performs a division on the conflict var by applying rounded division on its weight.
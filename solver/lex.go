@@ -0,0 +1,63 @@
+package solver
+
+// LexMin returns the lexicographically smallest model of the problem with respect to the given order of vars,
+// false being smaller than true. That is, the first var in order is false if there is any model where it is false,
+// then the second var is false if there is any such model where it is also false, and so on.
+// If order is nil, all vars are considered, by increasing index.
+// Vars that are not in order are bound so that the model is a valid one, but their bindings are not canonical:
+// for the result to be fully reproducible, order must contain all the vars of the problem.
+// The model is computed by greedily fixing vars in order, each fixing being checked by a SAT call under assumptions.
+// LexMin returns nil if the problem is UNSAT.
+// Current assumptions, if any, are discarded, but the solver can be used as usual afterwards.
+func (s *Solver) LexMin(order []Var) []bool {
+	return s.lexModel(order, false)
+}
+
+// LexMax is like LexMin, but it returns the lexicographically greatest model, i.e vars are preferably true.
+func (s *Solver) LexMax(order []Var) []bool {
+	return s.lexModel(order, true)
+}
+
+// lexModel returns the lexicographically optimal model with respect to order,
+// where the preferred binding of each var is given by pref.
+func (s *Solver) lexModel(order []Var, pref bool) []bool {
+	if s.trivialUnsat {
+		return nil
+	}
+	if order == nil {
+		order = make([]Var, s.nbUserVars)
+		for i := range order {
+			order[i] = Var(i)
+		}
+	}
+	oldPolarity := make([]bool, len(order))
+	for i, v := range order {
+		oldPolarity[i] = s.polarity[v]
+		s.polarity[v] = pref // Start search from the preferred bindings
+	}
+	defer func() {
+		for i := len(order) - 1; i >= 0; i-- { // Reverse order, in case a var appears several times in order
+			s.polarity[order[i]] = oldPolarity[i]
+		}
+	}()
+	if s.Assume(nil) == Unsat || s.Solve() != Sat {
+		return nil
+	}
+	model := s.Model()
+	fixed := make([]Lit, 0, len(order))
+	for _, v := range order {
+		if model[v] == pref { // Current model is already compatible with the preferred binding
+			fixed = append(fixed, v.SignedLit(!pref))
+			continue
+		}
+		lit := v.SignedLit(!pref)
+		if s.Assume(append(fixed, lit)) != Unsat && s.Solve() == Sat {
+			model = s.Model()
+			fixed = append(fixed, lit)
+		} else {
+			fixed = append(fixed, lit.Negation())
+		}
+	}
+	s.Assume(nil)
+	return model
+}
@@ -0,0 +1,112 @@
+package solver
+
+// MinimalModel returns a model of the problem that is minimal with respect to the given vars,
+// i.e a model such that no other model sets to true a strict subset of the vars it sets to true.
+// This is the kind of models circumscription is interested in, vars typically being "abnormality" predicates.
+// Other vars are not minimized: they are bound so that the model is a valid one.
+// The model is obtained by solving the problem, then repeatedly asking for a model that sets to false
+// all the vars the current model sets to false, and at least one of the vars it sets to true.
+// MinimalModel returns nil if the problem is UNSAT.
+// Current assumptions, if any, are discarded, but the solver can be used as usual afterwards.
+func (s *Solver) MinimalModel(vars []Var) []bool {
+	if s.trivialUnsat {
+		return nil
+	}
+	oldPolarity := make([]bool, len(vars))
+	for i, v := range vars {
+		oldPolarity[i] = s.polarity[v]
+		s.polarity[v] = false // Start search from small models
+	}
+	defer func() {
+		for i := len(vars) - 1; i >= 0; i-- { // Reverse order, in case a var appears several times in vars
+			s.polarity[vars[i]] = oldPolarity[i]
+		}
+	}()
+	if s.Assume(nil) == Unsat || s.Solve() != Sat {
+		return nil
+	}
+	return s.minimizeModel(vars, nil)
+}
+
+// EnumerateMinimal enumerates all the models of the problem that are minimal with respect to the given vars,
+// as defined by MinimalModel, and returns their number.
+// Two minimal models always differ on at least one var in vars: models that only differ on other vars are not reported.
+// If "models" is non-nil, it will write each minimal model on it, as soon as it discovers it.
+// Once a minimal model is found, all models that set to true a superset of its true vars are blocked.
+// If stop is non-nil, enumeration stops as soon as a value is received or stop is closed.
+// models will be closed at the end of the method.
+// Current assumptions, if any, are discarded, but the solver can be used as usual afterwards.
+func (s *Solver) EnumerateMinimal(vars []Var, models chan []bool, stop chan struct{}) int {
+	if models != nil {
+		defer close(models)
+	}
+	if s.trivialUnsat {
+		return 0
+	}
+	oldPolarity := make([]bool, len(vars))
+	for i, v := range vars {
+		oldPolarity[i] = s.polarity[v]
+		s.polarity[v] = false // Start search from small models
+	}
+	defer func() {
+		for i := len(vars) - 1; i >= 0; i-- { // Reverse order, in case a var appears several times in vars
+			s.polarity[vars[i]] = oldPolarity[i]
+		}
+	}()
+	block := s.newSelector() // Blocking clauses are only active when block is true
+	nb := 0
+	for {
+		select {
+		case <-stop:
+			s.disableSelector(block)
+			return nb
+		default:
+		}
+		if s.Assume([]Lit{block}) == Unsat || s.Solve() != Sat {
+			break
+		}
+		model := s.minimizeModel(vars, []Lit{block})
+		nb++
+		if models != nil {
+			models <- model
+		}
+		lits := []Lit{block.Negation()}
+		for _, v := range vars {
+			if model[v] {
+				lits = append(lits, v.Lit().Negation())
+			}
+		}
+		s.AppendClause(NewClause(lits))
+	}
+	s.disableSelector(block)
+	return nb
+}
+
+// minimizeModel returns a model that is minimal with respect to vars, under the given assumptions.
+// s must have just found a model under those assumptions.
+func (s *Solver) minimizeModel(vars []Var, assumptions []Lit) []bool {
+	model := s.Model()
+	// Each model sets to false all the vars the previous one set to false, so the clauses added for previous models
+	// are implied by the assumptions of the current one: all those clauses can share the same selector.
+	sel := s.newSelector()
+	defer s.disableSelector(sel)
+	for {
+		var lits []Lit // At least one of the true vars must become false
+		assumed := append([]Lit{}, assumptions...)
+		for _, v := range vars {
+			if model[v] {
+				lits = append(lits, v.Lit().Negation())
+			} else {
+				assumed = append(assumed, v.Lit().Negation())
+			}
+		}
+		if len(lits) == 0 { // No true var: model is minimal
+			return model
+		}
+		s.AppendClause(NewClause(append(lits, sel.Negation())))
+		if s.Assume(append(assumed, sel)) == Unsat || s.Solve() != Sat {
+			return model
+		}
+		model = s.Model()
+	}
+}
@@ -117,3 +117,150 @@ func runOptimBench(path string, b *testing.B) {
 func BenchmarkLo88(b *testing.B) {
 	runOptimBench("testcnf/lo_8x8_009.opb", b)
 }
+
+func TestHint(t *testing.T) {
+	const path = "testcnf/lo_8x8_009.opb"
+	parse := func() *Problem {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		defer func() { _ = f.Close() }()
+		pb, err := ParseOPB(f)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return pb
+	}
+	s := New(parse())
+	res := s.Optimal(nil, nil)
+	if res.Status != Sat {
+		t.Fatalf("expected sat for %q, got %v", path, res.Status)
+	}
+	// An optimal hint must be reported right away as the first solution.
+	s = New(parse())
+	s.SetHint(res.Model)
+	results := make(chan Result)
+	go s.Optimal(results, nil)
+	first := true
+	for res2 := range results {
+		if first && res2.Weight != res.Weight {
+			t.Errorf("expected hint of cost %d to be the first solution, got cost %d", res.Weight, res2.Weight)
+		}
+		first = false
+	}
+	// An infeasible hint must be repaired.
+	s = New(parse())
+	s.SetHint(make([]bool, len(res.Model)))
+	if cost := s.Minimize(); cost != res.Weight {
+		t.Errorf("invalid cost with infeasible hint: expected %d, got %d", res.Weight, cost)
+	}
+}
+
+func TestTopK(t *testing.T) {
+	const opb = `* #variable= 3 #constraint= 1
+min: +1 x1 +2 x2 +3 x3 ;
++1 x1 +1 x2 +1 x3 >= 1 ;
+`
+	pb, err := ParseOPB(strings.NewReader(opb))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	s := New(pb)
+	res := s.TopK(5, nil)
+	costs := []int{1, 2, 3, 3, 4}
+	if len(res) != len(costs) {
+		t.Fatalf("expected %d results, got %d", len(costs), len(res))
+	}
+	for i, r := range res {
+		if r.Weight != costs[i] {
+			t.Errorf("invalid cost for result #%d: expected %d, got %d", i, costs[i], r.Weight)
+		}
+		for j := 0; j < i; j++ {
+			same := true
+			for v := range r.Model {
+				if r.Model[v] != res[j].Model[v] {
+					same = false
+					break
+				}
+			}
+			if same {
+				t.Errorf("results #%d and #%d are the same model", j, i)
+			}
+		}
+	}
+	if res := s.TopK(10, nil); len(res) != 7 {
+		t.Errorf("expected 7 models, got %d", len(res))
+	}
+	if res := s.TopK(10, []Var{0, 1, 2, 1, 0, 2}); len(res) != 7 {
+		t.Errorf("expected 7 models with duplicate vars, got %d", len(res))
+	}
+	// TopK must not constrain the solver for good
+	if cost := s.Minimize(); cost != 1 {
+		t.Errorf("invalid cost after TopK: expected 1, got %d", cost)
+	}
+}
+
+func TestTopKDecision(t *testing.T) {
+	s := New(ParseSlice([][]int{{1, 2}}))
+	if res := s.TopK(5, nil); len(res) != 3 {
+		t.Errorf("expected 3 models, got %d", len(res))
+	}
+	if res := s.TopK(5, []Var{0}); len(res) != 2 {
+		t.Errorf("expected 2 projected models, got %d", len(res))
+	}
+	if status := s.Solve(); status != Sat {
+		t.Errorf("expected problem to still be sat, got %v", status)
+	}
+}
+
+func TestTopKModelSize(t *testing.T) {
+	const opb = `* #variable= 3 #constraint= 1
+min: +1 x1 +2 x2 +3 x3 ;
++1 x1 +1 x2 +1 x3 >= 1 ;
+`
+	pb, err := ParseOPB(strings.NewReader(opb))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	s := New(pb)
+	for i := 0; i < 3; i++ {
+		for _, r := range s.TopK(2, nil) {
+			if len(r.Model) != 3 {
+				t.Errorf("call #%d: expected a model with 3 bindings, got %v", i, r.Model)
+			}
+		}
+		if status := s.Solve(); status != Sat {
+			t.Fatalf("expected problem to still be sat, got %v", status)
+		}
+		if model := s.Model(); len(model) != 3 {
+			t.Errorf("call #%d: expected Model() to have 3 bindings, got %v", i, model)
+		}
+	}
+}
+
+func TestClosest(t *testing.T) {
+	s := New(ParseSlice([][]int{{1, 2}, {-1, -2}, {-2, 3}}))
+	ref := []Lit{IntToLit(1), IntToLit(2), IntToLit(-3)}
+	res := s.Closest(ref, []int{1, 3, 1})
+	if res.Status != Sat {
+		t.Fatalf("expected sat, got %v", res.Status)
+	}
+	if res.Weight != 2 {
+		t.Errorf("invalid distance: expected 2, got %d", res.Weight)
+	}
+	if res.Model[0] || !res.Model[1] || !res.Model[2] {
+		t.Errorf("invalid model %v", res.Model)
+	}
+	if res2 := s.ClosestModel(res.Model, nil); res2.Weight != 0 {
+		t.Errorf("expected model to be at distance 0 from itself, got %d", res2.Weight)
+	}
+	if res2 := s.Closest(ref, nil); res2.Weight != 1 {
+		t.Errorf("invalid unweighted distance: expected 1, got %d", res2.Weight)
+	}
+	for v, pol := range s.polarity[:3] {
+		if pol {
+			t.Errorf("polarity of var %d was not restored", v+1)
+		}
+	}
+}
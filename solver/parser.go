@@ -2,6 +2,7 @@ package solver
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"strconv"
@@ -26,7 +27,12 @@ func ParseSliceNb(cnf [][]int, nbVars int) *Problem {
 }
 
 func (pb *Problem) parseSlice(cnf [][]int) {
-	for _, line := range cnf {
+	pb.formula = make([]PBConstr, len(cnf))
+	pb.origins = make(map[*Clause]int, len(cnf))
+	for i, line := range cnf {
+		pb.formula[i] = PropClause(line...)
+	}
+	for i, line := range cnf {
 		switch len(line) {
 		case 0:
 			pb.Status = Unsat
@@ -52,7 +58,9 @@ func (pb *Problem) parseSlice(cnf [][]int) {
 					pb.NbVars = v + 1
 				}
 			}
-			pb.Clauses = append(pb.Clauses, NewClause(lits))
+			c := NewClause(lits)
+			pb.Clauses = append(pb.Clauses, c)
+			pb.origins[c] = i
 		}
 	}
 	pb.Model = make([]decLevel, pb.NbVars)
@@ -138,7 +146,7 @@ func ParseCNF(f io.Reader) (*Problem, error) {
 	r := bufio.NewReader(f)
 	var (
 		nbClauses int
-		pb        Problem
+		pb        = Problem{formula: []PBConstr{}, origins: make(map[*Clause]int)}
 	)
 	b, err := r.ReadByte()
 	for err == nil {
@@ -160,7 +168,7 @@ func ParseCNF(f io.Reader) (*Problem, error) {
 				val, err := readInt(&b, r)
 				if err == io.EOF {
 					if len(lits) != 0 { // This is not a trailing space at the end...
-						pb.Clauses = append(pb.Clauses, NewClause(lits))
+						pb.appendCNFClause(lits)
 					}
 					break // When there are only several useless spaces at the end of the file, that is ok
 				}
@@ -168,7 +176,7 @@ func ParseCNF(f io.Reader) (*Problem, error) {
 					return nil, fmt.Errorf("cannot parse clause: %v", err)
 				}
 				if val == 0 {
-					pb.Clauses = append(pb.Clauses, NewClause(lits))
+					pb.appendCNFClause(lits)
 					break
 				} else {
 					if val > pb.NbVars || -val > pb.NbVars {
@@ -186,3 +194,83 @@ func ParseCNF(f io.Reader) (*Problem, error) {
 	pb.simplify2()
 	return &pb, nil
 }
+
+// appendCNFClause appends the clause made of the given lits to the problem, and to the formula it is built from.
+func (pb *Problem) appendCNFClause(lits []Lit) {
+	vals := make([]int, len(lits))
+	for i, lit := range lits {
+		vals[i] = int(lit.Int())
+	}
+	c := NewClause(lits)
+	pb.Clauses = append(pb.Clauses, c)
+	pb.origins[c] = len(pb.formula)
+	pb.formula = append(pb.formula, PropClause(vals...))
+}
+
+// parseCNFWithComments parses a CNF file and returns the corresponding Problem.
+// Each comment line is also given to fn, along with its whitespace-separated fields, so that
+// additional data can be read from comments. If fn returns an error, parsing stops.
+func parseCNFWithComments(f io.Reader, fn func(line string, fields []string) error) (*Problem, error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not read problem: %v", err)
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, len(data)+1)
+	for sc.Scan() {
+		line := sc.Text()
+		if fields := strings.Fields(line); len(fields) > 0 && fields[0] == "c" {
+			if err := fn(line, fields); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("could not read problem: %v", err)
+	}
+	return ParseCNF(bytes.NewReader(data))
+}
+
+// ParseProjectedCNF parses a CNF file and returns the corresponding Problem, along with its sampling set,
+// i.e the set of vars models should be projected on.
+// The sampling set is given in comment lines of the form "c ind <var> ... <var> 0", as in the MIS format.
+// Lines of the form "c p show <var> ... <var> 0", as in the model counting competition, are also accepted.
+// If there are several such lines, the sampling set is the union of all vars.
+// If there is none, the returned set is nil.
+func ParseProjectedCNF(f io.Reader) (*Problem, []Var, error) {
+	var vars []Var
+	met := make(map[Var]bool)
+	pb, err := parseCNFWithComments(f, func(line string, fields []string) error {
+		switch {
+		case len(fields) >= 2 && fields[1] == "ind":
+			fields = fields[2:]
+		case len(fields) >= 3 && fields[1] == "p" && fields[2] == "show":
+			fields = fields[3:]
+		default:
+			return nil
+		}
+		for _, field := range fields {
+			val, err := strconv.Atoi(field)
+			if err != nil || val < 0 {
+				return fmt.Errorf("invalid var in sampling set line %q", line)
+			}
+			if val == 0 {
+				break
+			}
+			if v := IntToVar(int32(val)); !met[v] {
+				met[v] = true
+				vars = append(vars, v)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, v := range vars {
+		if int(v) >= pb.NbVars {
+			return nil, nil, fmt.Errorf("invalid var %d in sampling set for problem with %d vars only", v.Int(), pb.NbVars)
+		}
+	}
+	return pb, vars, nil
+}
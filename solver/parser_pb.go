@@ -65,18 +65,36 @@ func ParseCardConstrs(constrs []CardConstr) *Problem {
 	return &pb
 }
 
-func (pb *Problem) appendClause(constr PBConstr) {
+func (pb *Problem) appendClause(constr PBConstr) *Clause {
 	lits := make([]Lit, len(constr.Lits))
 	for j, val := range constr.Lits {
 		lits[j] = IntToLit(int32(val))
 	}
-	pb.Clauses = append(pb.Clauses, NewPBClause(lits, constr.Weights, constr.AtLeast))
+	c := NewPBClause(lits, constr.Weights, constr.AtLeast)
+	pb.Clauses = append(pb.Clauses, c)
+	return c
+}
+
+// appendFormula appends a copy of constr to the formula the problem is built from, and returns its index.
+func (pb *Problem) appendFormula(constr PBConstr) int {
+	lits := make([]int, len(constr.Lits))
+	copy(lits, constr.Lits)
+	var weights []int
+	if constr.Weights != nil {
+		weights = make([]int, len(constr.Weights))
+		copy(weights, constr.Weights)
+	}
+	pb.formula = append(pb.formula, PBConstr{Lits: lits, Weights: weights, AtLeast: constr.AtLeast})
+	return len(pb.formula) - 1
 }
 
 // ParsePBConstrs parses and returns a PB problem from PBConstr values.
 func ParsePBConstrs(constrs []PBConstr) *Problem {
-	var pb Problem
+	pb := Problem{formula: make([]PBConstr, 0, len(constrs)), origins: make(map[*Clause]int)}
 	for _, constr := range constrs {
+		pb.appendFormula(constr)
+	}
+	for idx, constr := range constrs {
 		for i := range constr.Lits {
 			lit := IntToLit(int32(constr.Lits[i]))
 			v := lit.Var()
@@ -108,7 +126,7 @@ func ParsePBConstrs(constrs []PBConstr) *Problem {
 				}
 			}
 		} else {
-			pb.appendClause(constr)
+			pb.origins[pb.appendClause(constr)] = idx
 		}
 	}
 	pb.Model = make([]decLevel, pb.NbVars)
@@ -158,29 +176,23 @@ func (pb *Problem) parsePBLine(line string) error {
 }
 
 func (pb *Problem) parsePBConstrLine(fields []string, line string) error {
-	if len(fields) < 3 {
-		return fmt.Errorf("invalid syntax %q", line)
-	}
-	operator := fields[len(fields)-2]
-	if operator != ">=" && operator != "=" {
-		return fmt.Errorf("invalid operator %q in %q: expected \">=\" or \"=\"", operator, line)
-	}
-	rhs, err := strconv.Atoi(fields[len(fields)-1])
-	if err != nil {
-		return fmt.Errorf("invalid value %q in %q: %v", fields[len(fields)-1], line, err)
-	}
-	weights, lits, err := pb.parseTerms(fields[:len(fields)-2], line)
+	constrs, err := pb.parsePBConstrs(fields, line)
 	if err != nil {
 		return err
 	}
-	var constrs []PBConstr
-	if operator == ">=" {
-		constrs = []PBConstr{GtEq(lits, weights, rhs)}
-	} else {
-		constrs = Eq(lits, weights, rhs)
-	}
+	first := len(pb.formula) // Index in the formula of the first constraint of the line
 	for _, constr := range constrs {
+		pb.appendFormula(constr)
+	}
+	if fields[len(fields)-2] == "=" {
+		pb.appendTrivialHalves(constrs)
+	}
+	for i, constr := range constrs {
+		idx := first + i
 		card := constr.AtLeast
+		if card <= 0 { // Clause is trivially SAT, ignore
+			continue
+		}
 		sumW := constr.WeightSum()
 		if sumW < card { // Clause cannot be satsfied
 			pb.Status = Unsat
@@ -196,12 +208,55 @@ func (pb *Problem) parsePBConstrLine(fields []string, line string) error {
 			for j, val := range constr.Lits {
 				lits[j] = IntToLit(int32(val))
 			}
-			pb.Clauses = append(pb.Clauses, NewPBClause(lits, constr.Weights, card))
+			c := NewPBClause(lits, constr.Weights, card)
+			pb.Clauses = append(pb.Clauses, c)
+			pb.origins[c] = idx
 		}
 	}
 	return nil
 }
 
+// appendTrivialHalves appends to the formula the halves of an equality that Eq ignored because they are trivially satisfied,
+// given the halves it kept, so that, as in VeriPB, each equality counts for two constraints in the formula.
+// Unless the equality has no weight at all, at most one half is ignored: it is the negation of the kept one,
+// that then binds all its lits. Since neither of them is ever turned into a clause, their order does not matter.
+func (pb *Problem) appendTrivialHalves(kept []PBConstr) {
+	switch len(kept) {
+	case 0:
+		pb.formula = append(pb.formula, PBConstr{}, PBConstr{})
+	case 1:
+		lits := make([]int, len(kept[0].Lits))
+		for i, lit := range kept[0].Lits {
+			lits[i] = -lit
+		}
+		pb.appendFormula(PBConstr{Lits: lits, Weights: kept[0].Weights, AtLeast: kept[0].WeightSum() - kept[0].AtLeast})
+	}
+}
+
+// parsePBConstrs returns the constraints described by the given OPB line.
+// A line with a ">=" operator is translated into a single constraint, and a line with a "=" operator into two constraints at most.
+func (pb *Problem) parsePBConstrs(fields []string, line string) ([]PBConstr, error) {
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("invalid syntax %q", line)
+	}
+	operator := fields[len(fields)-2]
+	if operator != ">=" && operator != "=" {
+		return nil, fmt.Errorf("invalid operator %q in %q: expected \">=\" or \"=\"", operator, line)
+	}
+	rhs, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q in %q: %v", fields[len(fields)-1], line, err)
+	}
+	weights, lits, err := pb.parseTerms(fields[:len(fields)-2], line)
+	if err != nil {
+		return nil, err
+	}
+	if operator == ">=" {
+		return []PBConstr{GtEq(lits, weights, rhs)}, nil
+	}
+	return Eq(lits, weights, rhs), nil
+}
+
 func (pb *Problem) parseTerms(terms []string, line string) (weights []int, lits []int, err error) {
 	weights = make([]int, 0, len(terms)/2)
 	lits = make([]int, 0, len(terms)/2)
@@ -247,7 +302,7 @@ func (pb *Problem) parseTerms(terms []string, line string) (weights []int, lits
 // See http://www.cril.univ-artois.fr/PB16/format.pdf for more details.
 func ParseOPB(f io.Reader) (*Problem, error) {
 	scanner := bufio.NewScanner(f)
-	var pb Problem
+	pb := Problem{formula: []PBConstr{}, origins: make(map[*Clause]int)}
 	for scanner.Scan() {
 		line := scanner.Text()
 		if line == "" || line[0] == '*' {
@@ -264,3 +319,37 @@ func ParseOPB(f io.Reader) (*Problem, error) {
 	pb.simplifyPB()
 	return &pb, nil
 }
+
+// ParseOPBConstrs parses a file corresponding to the OPB syntax, and returns its constraints, as they appear in the file,
+// along with the number of vars of the problem.
+// Contrary to ParseOPB, constraints are not simplified, so that each of them can be traced back to the file.
+// Each line with a "=" operator is translated into two constraints, and the cost function, if any, is ignored.
+func ParseOPBConstrs(f io.Reader) (constrs []PBConstr, nbVars int, err error) {
+	scanner := bufio.NewScanner(f)
+	var pb Problem
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '*' {
+			continue
+		}
+		if line[len(line)-1] != ';' {
+			return nil, 0, fmt.Errorf("line %q does not end with semicolon", line)
+		}
+		fields := strings.Fields(line[:len(line)-1])
+		if len(fields) == 0 {
+			return nil, 0, fmt.Errorf("empty line in file")
+		}
+		if fields[0] == "min:" {
+			continue
+		}
+		lineConstrs, err := pb.parsePBConstrs(fields, line)
+		if err != nil {
+			return nil, 0, err
+		}
+		constrs = append(constrs, lineConstrs...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("could not parse OPB: %v", err)
+	}
+	return constrs, pb.NbVars, nil
+}
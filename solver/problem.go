@@ -13,6 +13,10 @@ type Problem struct {
 	Model      []decLevel // For each var, its inferred binding. 0 means unbound, 1 means bound to true, -1 means bound to false.
 	minLits    []Lit      // For an optimisation problem, the list of lits whose sum must be minimized
 	minWeights []int      // For an optimisation problem, the weight of each lit.
+	// Constraints the problem was built from, in order, if known. They are the formula VeriPB proofs refer to.
+	formula []PBConstr
+	// For each clause, the index in formula of the constraint it was built from.
+	origins map[*Clause]int
 }
 
 // Optim returns true iff pb is an optimisation problem, ie
@@ -0,0 +1,87 @@
+package solver
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+)
+
+// Parameters of the sampler, as in UniGen, for a tolerance of 16.
+const (
+	sampleKappa    = 0.638
+	samplePivot    = 27 // ceil(4.03 * (1 + 1/kappa)^2)
+	sampleHiThresh = 45 // 1 + (1 + kappa) * pivot
+	sampleLoThresh = 16 // pivot / (1 + kappa)
+	maxSampleTries = 20 // Max number of failed attempts per sample, before the sampler gives up
+)
+
+// Sample returns n models of the problem, drawn near-uniformly among all models, projected on the sampling set.
+// That is, each assignment of the vars in sampling that can be extended to a model is returned
+// with roughly the same probability. Vars that are not in sampling are bound arbitrarily.
+// If sampling is nil, all vars are considered.
+// If the problem has few models, they are all enumerated and samples are drawn uniformly among them. Else,
+// samples are drawn in the style of UniGen: random XOR constraints over the sampling set split the models
+// into small cells of roughly equal size, and a model is drawn uniformly from a random cell.
+// Samples are drawn with replacement, so the same model can appear several times.
+// The random choices are made with the given seed, so that results are reproducible.
+// Sample returns nil if the problem is UNSAT. In the unlikely case the sampler keeps failing to find
+// a suitable cell, less than n models can be returned.
+// As with Count, current top-level bindings, including assumptions, are taken into account, and the state of the solver is not modified.
+func (s *Solver) Sample(n int, sampling []Var, seed int64) [][]bool {
+	if n <= 0 || s.status == Unsat {
+		return nil
+	}
+	if sampling == nil {
+		sampling = make([]Var, s.nbUserVars)
+		for i := range sampling {
+			sampling[i] = Var(i)
+		}
+	}
+	rng := rand.New(rand.NewSource(seed))
+	s2 := s.clone()
+	models := s2.boundedModels(nil, sampling, sampleHiThresh+1)
+	if len(models) == 0 {
+		return nil
+	}
+	res := make([][]bool, 0, n)
+	if len(models) <= sampleHiThresh { // Few models: draw them uniformly
+		for len(res) < n {
+			res = append(res, models[rng.Intn(len(models))])
+		}
+		return res
+	}
+	xors := &xorCells{s: s2}
+	est := s2.approxCount(sampling, 0.8, 0.2, xors, rand.New(rand.NewSource(rng.Int63())))
+	count, _ := new(big.Float).SetInt(est.Count).Float64()
+	q := int(math.Ceil(math.Log2(count) + math.Log2(1.8) - math.Log2(samplePivot)))
+	for tries := 0; len(res) < n && tries < maxSampleTries; {
+		if model := s2.sampleCell(sampling, q, xors, rng); model != nil {
+			res = append(res, model)
+			tries = 0
+		} else {
+			tries++
+		}
+	}
+	return res
+}
+
+// sampleCell tries to draw a model in a random cell, defined by q-3 to q random XOR constraints.
+// s is supposed to be a clone of the solver the user called Sample on.
+// It returns nil if no cell of a suitable size was found.
+// XORs are added by xors, and they are disabled before returning, so that s can be used for the next attempt.
+func (s *Solver) sampleCell(sampling []Var, q int, xors *xorCells, rng *rand.Rand) []bool {
+	defer xors.reset()
+	for m := q - 3; m <= q; m++ {
+		if m < 0 {
+			continue
+		}
+		for len(xors.sels) < m {
+			xors.add(sampling, rng)
+		}
+		models := s.boundedModels(xors.sels, sampling, sampleHiThresh+1)
+		if len(models) >= sampleLoThresh && len(models) <= sampleHiThresh {
+			return models[rng.Intn(len(models))]
+		}
+	}
+	return nil
+}
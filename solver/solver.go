@@ -13,6 +13,7 @@ const (
 	incrPostponeNbMax = 1_000 // By how much # of learned is increased when lots of good clauses are currently learned.
 	clauseDecay       = 0.999 // By how much clauses bumping decays over time.
 	defaultVarDecay   = 0.8   // On each var decay, how much the varInc should be decayed at startup
+	cleanupPeriod     = 16    // How many selectors are disabled between two recyclings of selectors.
 )
 
 // Stats are statistics about the resolution of the problem.
@@ -59,6 +60,10 @@ type Solver struct {
 	CertChan      chan string // Indicates where to write the certificate. If Certified is true but CertChan is nil, the certificate will be written on stdout.
 	CuttingPlanes bool        // Indicates that the cutting planes resolution method should be used. Note that this is only efficient on PB problems.
 	nbVars        int
+	nbUserVars    int    // Number of vars of the problem; vars beyond it are selectors, added internally by newSelector
+	disabled      []bool // For each var, true iff it is a selector that was disabled by disableSelector
+	disabledSels  []Var  // Selectors disabled since the last call to recycleSelectors
+	freeSels      []Var  // Disabled selectors that no constraint mentions anymore, and that can be reused
 	status        Status
 	wl            watcherList
 	trail         []Lit     // Current assignment stack
@@ -67,6 +72,19 @@ type Solver struct {
 	activity      []float64 // How often each var is involved in conflicts
 	polarity      []bool    // Preferred sign for each var
 	assumptions   []bool    // True iff the var's binding is assumed
+	units         []Lit     // Top-level bindings that hold no matter the assumptions: problem units, learned units, etc.
+	core          []Lit     // Failed assumptions, if the last call to Assume or Solve returned Unsat
+	maxConflicts  int       // Max number of conflicts per call to Solve, or 0 if there is no limit
+	deadline      time.Time // Time after which calls to Solve stop, or zero if there is no limit
+	budgetStart   int       // Number of conflicts when Solve was last called
+	trivialUnsat  bool      // True iff the problem is known to be UNSAT no matter the assumptions, e.g when the solver was created
+	// Partial interpolants of clauses, if an interpolant is being computed.
+	itp *interpolation
+	// Constraints the problem was built from, and the index of the constraint each clause was built from, if known.
+	formula []PBConstr
+	origins map[*Clause]int
+	// VeriPB proof being logged, if any.
+	proof *proof
 	// For each var, clause considered when it was unified
 	// If the var is not bound yet, or if it was bound by a decision, value is nil.
 	reason          []*Clause
@@ -79,11 +97,13 @@ type Solver struct {
 	minLits         []Lit   // Lits to minimize if the problem was an optimization problem.
 	minWeights      []int   // Weight of each lit to minimize if the problem was an optimization problem.
 	hypothesis      []Lit   // Literals that are, ideally, true. Useful when trying to minimize a function.
+	hint            []bool  // Model provided by the user through SetHint, if any. Consumed by the first call to Optimal or Minimize.
 	localNbRestarts int     // How many restarts since Solve() was called?
 	varDecay        float64 // On each var decay, how much the varInc should be decayed
 	trailBuf        []int   // A buffer while cleaning bindings
 	pbSetBuf        []int   // A buffer to reduce allocation when performing cutting planes
 	pbSetBuf2       []int   // A buffer to reduce allocation when performing cutting planes
+	litsBuf         []Lit   // A buffer for lits in learnClause. Used to reduce allocations.
 }
 
 // New makes a solver, given a number of variables and a set of clauses.
@@ -91,7 +111,7 @@ type Solver struct {
 // the biggest variable in clauses should be >= nbVars.
 func New(problem *Problem) *Solver {
 	if problem.Status == Unsat {
-		return &Solver{status: Unsat}
+		return &Solver{status: Unsat, trivialUnsat: true, formula: problem.formula}
 	}
 	nbVars := problem.NbVars
 
@@ -102,12 +122,14 @@ func New(problem *Problem) *Solver {
 
 	s := &Solver{
 		nbVars:          nbVars,
+		nbUserVars:      nbVars,
 		status:          problem.Status,
 		trail:           make([]Lit, len(problem.Units), trailCap),
 		model:           problem.Model,
 		activity:        make([]float64, nbVars),
 		polarity:        make([]bool, nbVars),
 		assumptions:     make([]bool, nbVars),
+		disabled:        make([]bool, nbVars),
 		reason:          make([]*Clause, nbVars),
 		varInc:          1.0,
 		clauseInc:       1.0,
@@ -118,7 +140,12 @@ func New(problem *Problem) *Solver {
 		trailBuf:        make([]int, nbVars),
 		pbSetBuf:        make([]int, nbVars),
 		pbSetBuf2:       make([]int, nbVars),
+		litsBuf:         make([]Lit, nbVars+1),
+		units:           make([]Lit, len(problem.Units)),
+		formula:         problem.formula,
+		origins:         problem.origins,
 	}
+	copy(s.units, problem.Units)
 	s.resetOptimPolarity()
 	s.initOptimActivity()
 	s.initWatcherList(problem.Clauses)
@@ -145,7 +172,11 @@ func (s *Solver) newVar(v Var) {
 			s.activity = append(s.activity, 0.)
 			s.polarity = append(s.polarity, false)
 			s.reason = append(s.reason, nil)
+			s.assumptions = append(s.assumptions, false)
+			s.disabled = append(s.disabled, false)
 			s.trailBuf = append(s.trailBuf, 0)
+			s.pbSetBuf = append(s.pbSetBuf, 0)
+			s.pbSetBuf2 = append(s.pbSetBuf2, 0)
 		}
 		s.varQueue = newQueue(s.activity)
 		s.addVarWatcherList(v)
@@ -404,9 +435,24 @@ func (s *Solver) propagateAndSearch(lit Lit, lvl decLevel) Status {
 				s.varDecay += 0.01
 			}
 			s.lbdStats.addConflict(len(s.trail))
+			if s.budgetExceeded() {
+				s.cleanupBindings(1)
+				return Indet
+			}
 			learnt, unit := s.learnClause(conflict, lvl)
 			if learnt == nil { // Unit clause was learned: this lit is known for sure
-				if unit == -1 || (abs(s.model[unit.Var()]) == 1 && s.litStatus(unit) == Unsat) { // Top-level conflict
+				if unit == -1 { // Top-level conflict
+					s.analyzeFinal(conflict.lits)
+					if s.itp != nil {
+						s.finishInterpolant(conflict, conflict.lits)
+					}
+					return s.setUnsat()
+				}
+				if abs(s.model[unit.Var()]) == 1 && s.litStatus(unit) == Unsat { // Top-level conflict
+					s.analyzeFinal([]Lit{unit})
+					if s.itp != nil {
+						s.finishInterpolant(nil, []Lit{unit})
+					}
 					return s.setUnsat()
 				}
 				s.Stats.NbUnitLearned++
@@ -415,6 +461,10 @@ func (s *Solver) propagateAndSearch(lit Lit, lvl decLevel) Status {
 				s.addLearnedUnit(unit)
 				s.model[unit.Var()] = lvlToSignedLvl(unit, 1)
 				if conflict = s.unifyLiteral(unit, 1); conflict != nil { // top-level conflict
+					s.analyzeFinal(conflict.lits)
+					if s.itp != nil {
+						s.finishInterpolant(conflict, conflict.lits)
+					}
 					return s.setUnsat()
 				}
 				s.rebuildOrderHeap()
@@ -426,6 +476,9 @@ func (s *Solver) propagateAndSearch(lit Lit, lvl decLevel) Status {
 				}
 				s.Stats.NbLearned++
 				s.lbdStats.addLbd(learnt.lbd())
+				if s.proof != nil {
+					s.proofLearned(learnt)
+				}
 				s.addLearned(learnt)
 				lvl, lit = backtrackData(learnt, s.model)
 				s.cleanupBindings(lvl)
@@ -462,14 +515,24 @@ func (s *Solver) propagateAndSearchPB(lit Lit, lvl decLevel) Status {
 					s.varDecay += 0.01
 				}
 				s.lbdStats.addConflict(len(s.trail))
+				if s.budgetExceeded() {
+					s.cleanupBindings(1)
+					return Indet
+				}
 				learnt, propagated, newLvl := s.cuttingPlanes(conflict, lvl)
 				// log.Printf("learnt=%v, propagated=%v, newLvl=%d", learnt, propagated, newLvl)
 				if newLvl == -1 { // Generated constraint is false
+					s.analyzeFinal(nil)
 					return s.setUnsat()
 				}
 				if newLvl == 1 {
 					for _, unit := range propagated {
-						if unit == -1 || (abs(s.model[unit.Var()]) == 1 && s.litStatus(unit) == Unsat) { // Top-level conflict
+						if unit == -1 {
+							s.analyzeFinal(nil)
+							return s.setUnsat()
+						}
+						if abs(s.model[unit.Var()]) == 1 && s.litStatus(unit) == Unsat { // Top-level conflict
+							s.analyzeFinal([]Lit{unit})
 							return s.setUnsat()
 						}
 						s.Stats.NbUnitLearned++
@@ -478,6 +541,7 @@ func (s *Solver) propagateAndSearchPB(lit Lit, lvl decLevel) Status {
 						s.addLearnedUnit(unit)
 						s.model[unit.Var()] = lvlToSignedLvl(unit, 1)
 						if conflict = s.unifyLiteral(unit, 1); conflict != nil { // top-level conflict
+							s.analyzeFinal(conflict.lits)
 							return s.setUnsat()
 						}
 					}
@@ -540,9 +604,13 @@ func (s *Solver) search() Status {
 // Solve solves the problem associated with the solver and returns the appropriate status.
 func (s *Solver) Solve() Status {
 	if s.status == Unsat {
+		if s.proof != nil {
+			s.proofUnsat()
+		}
 		return s.status
 	}
 	s.status = Indet
+	s.budgetStart = s.Stats.NbConflicts
 	//s.lbdStats.clear()
 	s.localNbRestarts = 0
 	var end chan struct{}
@@ -574,7 +642,7 @@ func (s *Solver) Solve() Status {
 			}
 		}()
 	}
-	for s.status == Indet {
+	for s.status == Indet && !s.budgetExceeded() {
 		s.search()
 		if s.status == Indet {
 			s.Stats.NbRestarts++
@@ -584,6 +652,8 @@ func (s *Solver) Solve() Status {
 	if s.status == Sat {
 		s.lastModel = make(Model, len(s.model))
 		copy(s.lastModel, s.model)
+	} else if s.status == Unsat && s.proof != nil {
+		s.proofUnsat()
 	}
 	if s.Verbose {
 		end <- struct{}{}
@@ -594,26 +664,271 @@ func (s *Solver) Solve() Status {
 
 // Assume adds unit literals to the solver.
 // This is useful when calling the solver several times, e.g to keep it "hot" while removing clauses.
+// Previous assumptions, if any, are discarded. Calling Assume(nil) thus removes all assumptions.
+// Assumptions are also discarded when a clause is appended to the solver.
+// Contrary to units, assumptions are not added to the problem for good: if an assumption contradicts the problem,
+// Assume or the next call to Solve returns Unsat, and the failed assumptions can be retrieved with FailedAssumptions.
 func (s *Solver) Assume(lits []Lit) Status {
+	if s.trivialUnsat {
+		return Unsat
+	}
 	s.cleanupBindings(0)
 	s.trail = s.trail[:0]
 	s.assumptions = make([]bool, s.nbVars)
-
+	s.core = nil
+	s.status = Indet
+	for _, unit := range s.units {
+		switch s.litStatus(unit) {
+		case Unsat:
+			s.status = Unsat
+			return s.status
+		case Indet:
+			s.model[unit.Var()] = lvlToSignedLvl(unit, 1)
+			s.trail = append(s.trail, unit)
+		}
+	}
 	for _, lit := range lits {
-
-		s.addLearnedUnit(lit)
-		s.assumptions[lit.Var()] = true
-		s.trail = append(s.trail, lit)
+		switch s.litStatus(lit) {
+		case Unsat:
+			s.analyzeFinal([]Lit{lit})
+			s.core = append(s.core, lit)
+			// Record the failed assumption, so that dropAssumptions resets the status.
+			s.assumptions[lit.Var()] = true
+			s.status = Unsat
+			return s.status
+		case Indet:
+			s.model[lit.Var()] = lvlToSignedLvl(lit, 1)
+			s.assumptions[lit.Var()] = true
+			s.trail = append(s.trail, lit)
+		}
 	}
-	s.status = Indet
 	if confl := s.propagate(0, 1); confl != nil {
 		// Conflict after unit propagation
+		s.analyzeFinal(confl.lits)
+		if s.itp != nil {
+			s.finishInterpolant(confl, confl.lits)
+		}
 		s.status = Unsat
 		return s.status
 	}
 	return s.status
 }
 
+// SetHint gives the solver a model that is expected to be close to a solution,
+// typically the solution of a slightly different version of the problem.
+// The preferred polarity of each variable is set to its binding in the hint, so that
+// the search starts from the hint and, if the hint does not satisfy the problem,
+// repairs it rather than starting from scratch.
+// If the hint satisfies the problem, it will also be used as the initial upper bound by the next call to Optimal or Minimize.
+// The hint can be partial, i.e shorter than the number of variables: remaining variables keep their default polarity,
+// but a partial hint will never be used as an upper bound.
+// SetHint will panic if len(model) is greater than the number of variables in the problem.
+func (s *Solver) SetHint(model []bool) {
+	if s.status == Unsat { // Trivially UNSAT: nothing to repair
+		return
+	}
+	if len(model) > s.nbUserVars {
+		panic(fmt.Sprintf("hint has %d bindings but problem only has %d vars", len(model), s.nbUserVars))
+	}
+	copy(s.polarity, model)
+	s.hint = make([]bool, len(model))
+	copy(s.hint, model)
+}
+
+// satisfies returns true iff the given complete assignment satisfies all the constraints of the problem,
+// including top-level bindings.
+func (s *Solver) satisfies(model []bool) bool {
+	for v, lvl := range s.model {
+		if abs(lvl) == 1 && (lvl > 0) != model[v] {
+			return false
+		}
+	}
+	for _, c := range s.wl.origClauses {
+		sum := 0
+		for i := 0; i < c.Len(); i++ {
+			if lit := c.Get(i); model[lit.Var()] == lit.IsPositive() {
+				sum += c.Weight(i)
+			}
+		}
+		if sum < c.Cardinality() {
+			return false
+		}
+	}
+	return true
+}
+
+// solveOrUseHint solves the problem and returns its status.
+// However, if a complete hint satisfying the problem was provided, no search is performed:
+// the hint is considered as the last model found and Sat is returned.
+// In any case, the hint will not be used again afterwards.
+func (s *Solver) solveOrUseHint() Status {
+	hint := s.hint
+	s.hint = nil
+	if s.status == Unsat || len(hint) != s.nbUserVars {
+		return s.Solve()
+	}
+	hint = append(hint, make([]bool, s.nbVars-s.nbUserVars)...) // Selectors are all disabled, i.e false
+	if !s.satisfies(hint) {
+		return s.Solve()
+	}
+	s.lastModel = make(Model, s.nbVars)
+	for i, b := range hint {
+		if b {
+			s.lastModel[i] = 1
+		} else {
+			s.lastModel[i] = -1
+		}
+	}
+	return Sat
+}
+
+// dropAssumptions discards the current assumptions, if any, but keeps top-level units.
+func (s *Solver) dropAssumptions() {
+	for _, assumed := range s.assumptions {
+		if assumed {
+			s.Assume(nil)
+			return
+		}
+	}
+}
+
+// newSelector adds a fresh variable to the solver and returns its positive literal.
+// It is meant to be used as a selector, i.e a literal whose negation is added to clauses
+// so that those clauses are only active when the selector is assumed.
+// Selectors are not vars of the problem: they do not appear in models, and they must be disabled
+// with disableSelector before the method that created them returns.
+// Selectors that were disabled and recycled are reused first, so that the number of vars stays bounded.
+func (s *Solver) newSelector() Lit {
+	if n := len(s.freeSels); n > 0 {
+		v := s.freeSels[n-1]
+		s.releaseSelector(v)
+		return v.Lit()
+	}
+	v := Var(s.nbVars)
+	s.newVar(v)
+	return v.Lit()
+}
+
+// disableSelector disables the given selector for good, by appending the unit clause made of its negation.
+// Once in a while, disabled selectors are recycled, so that the constraints they guard do not slow down
+// propagation and their vars can be reused.
+func (s *Solver) disableSelector(sel Lit) {
+	s.AppendClause(NewClause([]Lit{sel.Negation()}))
+	s.disabled[sel.Var()] = true
+	s.disabledSels = append(s.disabledSels, sel.Var())
+	if len(s.disabledSels) >= cleanupPeriod {
+		s.recycleSelectors()
+	}
+}
+
+// recycleSelectors removes the constraints that mention the selectors disabled since its last call,
+// and makes those selectors available again. Problem constraints only contain their negation,
+// so they are satisfied for good, and learned constraints can always be removed.
+// Recycled selectors stay bound to false until they are reused, so they do not add spurious models.
+// Constraints that are satisfied at the top level are removed too.
+// When a proof is being logged, selectors are not recycled, since vars must keep their meaning in the proof.
+func (s *Solver) recycleSelectors() {
+	if s.proof == nil {
+		retired := make([]bool, s.nbVars)
+		for _, v := range s.disabledSels {
+			retired[v] = true
+		}
+		s.removeConstraints(func(c *Clause) bool {
+			for i := 0; i < c.Len(); i++ {
+				if retired[c.Get(i).Var()] {
+					return true
+				}
+			}
+			return false
+		})
+		s.freeSels = append(s.freeSels, s.disabledSels...)
+		s.Assume(nil) // Removed constraints might have been the reason of top-level bindings
+	}
+	s.disabledSels = s.disabledSels[:0]
+	if s.status != Unsat {
+		s.removeSatisfied()
+	}
+}
+
+// releaseSelector unbinds the recycled selector v, so that it can be used again, as a selector or as a var of the problem.
+// It panics if v is not a recycled selector, i.e if it is still in use.
+func (s *Solver) releaseSelector(v Var) {
+	i := 0
+	for i < len(s.freeSels) && s.freeSels[i] != v {
+		i++
+	}
+	if i == len(s.freeSels) {
+		panic(fmt.Sprintf("cannot use variable %d: index already used by a selector", v.Int()))
+	}
+	s.freeSels = append(s.freeSels[:i], s.freeSels[i+1:]...)
+	s.disabled[v] = false
+	for i, unit := range s.units {
+		if unit.Var() == v {
+			s.units = append(s.units[:i], s.units[i+1:]...)
+			break
+		}
+	}
+	for i, lit := range s.trail { // No constraint mentions v, so no other binding depends on it
+		if lit.Var() == v {
+			s.trail = append(s.trail[:i], s.trail[i+1:]...)
+			break
+		}
+	}
+	s.model[v] = 0
+	s.reason[v] = nil
+	s.polarity[v] = false
+	if !s.varQueue.contains(int(v)) {
+		s.varQueue.insert(int(v))
+	}
+}
+
+// addUserVar makes v a var of the problem, along with the vars before it that were not vars of the problem yet.
+// Selectors that used those indices are recycled first, so that their indices can be reused.
+func (s *Solver) addUserVar(v Var) {
+	if len(s.disabledSels) > 0 {
+		s.recycleSelectors()
+	}
+	for w := Var(s.nbUserVars); w <= v && int(w) < s.nbVars; w++ {
+		s.releaseSelector(w)
+	}
+	s.newVar(v)
+	if int(v) >= s.nbUserVars {
+		s.nbUserVars = int(v) + 1
+	}
+}
+
+// clone returns a new solver for the same constraints as s.
+// All the bindings of s at level 1, i.e its units and its current assumptions, become units of the new solver.
+// Learned clauses and the cost function, if any, are not copied.
+// This is useful when a procedure needs to add constraints to the problem without altering s.
+func (s *Solver) clone() *Solver {
+	s2 := New(&Problem{NbVars: s.nbVars, Model: make([]decLevel, s.nbVars)})
+	s2.nbUserVars = s.nbUserVars
+	for v, lvl := range s.model {
+		if abs(lvl) == 1 {
+			s2.AppendClause(NewClause([]Lit{Var(v).SignedLit(lvl < 0)}))
+		}
+	}
+	for _, c := range s.wl.origClauses {
+		lits := make([]Lit, c.Len())
+		copy(lits, c.lits)
+		switch {
+		case c.PseudoBoolean():
+			weights := make([]int, c.Len())
+			copy(weights, c.pbData.weights)
+			s2.AppendClause(NewPBClause(lits, weights, c.Cardinality()))
+		case c.Cardinality() > 1:
+			s2.AppendClause(NewCardClause(lits, c.Cardinality()))
+		default:
+			s2.AppendClause(NewClause(lits))
+		}
+	}
+	copy(s2.disabled, s.disabled)
+	s2.disabledSels = append([]Var{}, s.disabledSels...)
+	s2.freeSels = append([]Var{}, s.freeSels...)
+	return s2
+}
+
 // Enumerate returns the total number of models for the given problems.
 // if "models" is non-nil, it will write models on it as soon as it discovers them.
 // models will be closed at the end of the method.
@@ -661,7 +976,66 @@ func (s *Solver) Enumerate(models chan []bool, stop chan struct{}) int {
 	return nb
 }
 
+// EnumerateProjected returns the total number of models for the given problem, projected on the given vars,
+// i.e the number of distinct assignments of those vars that can be extended to a model.
+// If "models" is non-nil, it will write a model on it for each such assignment, as soon as it discovers it.
+// Only the bindings of vars are meaningful: other vars are bound so that the model is a valid one, but two models
+// will never differ only on vars that are not in vars.
+// Vars that appear several times in vars are only considered once.
+// If stop is non-nil, enumeration stops as soon as a value is received or stop is closed.
+// models will be closed at the end of the method.
+// Current assumptions, if any, are discarded, but the solver can be used as usual afterwards.
+func (s *Solver) EnumerateProjected(vars []Var, models chan []bool, stop chan struct{}) int {
+	if models != nil {
+		defer close(models)
+	}
+	if s.trivialUnsat {
+		return 0
+	}
+	vars = uniqueVars(vars)
+	block := s.newSelector() // Blocking clauses are only active when block is true
+	nb := 0
+	for {
+		select {
+		case <-stop:
+			s.disableSelector(block)
+			return nb
+		default:
+		}
+		if s.Assume([]Lit{block}) == Unsat || s.Solve() != Sat {
+			break
+		}
+		nb++
+		if models != nil {
+			models <- s.Model()
+		}
+		lits := make([]Lit, len(vars)+1)
+		for i, v := range vars {
+			lits[i] = v.SignedLit(s.model[v] > 0)
+		}
+		lits[len(vars)] = block.Negation()
+		s.AppendClause(NewClause(lits))
+	}
+	s.disableSelector(block)
+	return nb
+}
+
+// uniqueVars returns the vars in vars, in the same order, without duplicates.
+func uniqueVars(vars []Var) []Var {
+	seen := make(map[Var]bool, len(vars))
+	res := make([]Var, 0, len(vars))
+	for _, v := range vars {
+		if !seen[v] {
+			seen[v] = true
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
 // CountModels returns the total number of models for the given problem.
+// Models are enumerated one by one and counted as an int, so this is only practical for problems with few models.
+// For an exact count of a large number of models, use Count instead.
 func (s *Solver) CountModels() int {
 	var end chan struct{}
 	if s.Verbose {
@@ -758,6 +1132,7 @@ func (s *Solver) propagateUnits(units []Lit) {
 		s.lbdStats.addLbd(1)
 		s.Stats.NbUnitLearned++
 		s.cleanupBindings(1)
+		s.units = append(s.units, unit)
 		s.model[unit.Var()] = lvlToSignedLvl(unit, 1)
 		if s.unifyLiteral(unit, 1) != nil {
 			s.status = Unsat
@@ -807,7 +1182,13 @@ func (s *Solver) PBString() string {
 
 // AppendClause appends a new clause to the set of clauses.
 // This is not a learned clause, but a clause that is part of the problem added afterwards (during model counting, for instance).
+// Current assumptions, if any, are discarded first.
+// The clause can contain new variables. If methods that need internal selector variables
+// (TopK, Closest, EnumerateCubes, etc.) were already called, the indices of the new variables might have been
+// given to selectors: those selectors are recycled first. This is not possible while a VeriPB proof is being logged,
+// since variables must keep their meaning in the proof: AppendClause then panics.
 func (s *Solver) AppendClause(clause *Clause) {
+	s.dropAssumptions()
 	s.cleanupBindings(1)
 	card := clause.Cardinality()
 	minW := 0
@@ -815,7 +1196,9 @@ func (s *Solver) AppendClause(clause *Clause) {
 	i := 0
 	for i < clause.Len() {
 		lit := clause.Get(i)
-		s.newVar(lit.Var())
+		if v := lit.Var(); int(v) >= s.nbVars || s.disabled[v] {
+			s.addUserVar(v)
+		}
 		switch s.litStatus(lit) {
 		case Sat:
 			w := clause.Weight(i)
@@ -835,11 +1218,18 @@ func (s *Solver) AppendClause(clause *Clause) {
 	}
 	if maxW < card { // clause cannot be satisfied
 		s.status = Unsat
+		s.trivialUnsat = true // The clause is not kept: later calls to Assume must not forget the problem is UNSAT
 		return
 	}
 	if maxW == card { // Unit
 		s.propagateUnits(clause.lits)
+		if s.status == Unsat { // Top-level conflict, since assumptions were discarded
+			s.trivialUnsat = true
+		}
 	} else {
+		if clause.PseudoBoolean() { // Removing lits might have broken the order of weights
+			sort.Sort(&weightedLits{lits: clause.lits, weights: clause.pbData.weights})
+		}
 		s.appendClause(clause)
 	}
 }
@@ -850,8 +1240,8 @@ func (s *Solver) Model() []bool {
 	if s.lastModel == nil {
 		panic("cannot call Model() from a non-Sat solver")
 	}
-	res := make([]bool, s.nbVars)
-	for i, lvl := range s.lastModel {
+	res := make([]bool, s.nbUserVars)
+	for i, lvl := range s.lastModel[:s.nbUserVars] {
 		res[i] = lvl > 0
 	}
 	return res
@@ -863,29 +1253,32 @@ func (s *Solver) Model() []bool {
 // For instance, if there are 4 variables in the problem and only 1, 3 and 4 are bound,
 // there are actually 2 models currently: one with 2 set to true, the other with 2 set to false.
 func (s *Solver) addCurrentModels(ch chan []bool) int {
-	unbound := make([]int, 0, s.nbVars) // indices of unbound variables
-	var nb uint64 = 1                   // total number of models found
-	model := make([]bool, s.nbVars)     // partial model
-	for i, lvl := range s.lastModel {
+	unbound := make([]int, 0, s.nbUserVars) // indices of unbound variables
+	model := make([]bool, s.nbUserVars)     // partial model
+	for i, lvl := range s.lastModel[:s.nbUserVars] {
 		if lvl == 0 {
 			unbound = append(unbound, i)
-			nb *= 2
 		} else {
 			model[i] = lvl > 0
 		}
 	}
-	for i := uint64(0); i < nb; i++ {
-		for j := range unbound {
-			mask := uint64(1 << j)
-			cur := i & mask
-			idx := unbound[j]
-			model[idx] = cur != 0
-		}
+	nb := 0 // total number of models found
+	for {
 		model2 := make([]bool, len(model))
 		copy(model2, model)
 		ch <- model2
+		nb++
+		// Unbound vars are considered as the bits of a binary counter, that is incremented.
+		j := 0
+		for j < len(unbound) && model[unbound[j]] {
+			model[unbound[j]] = false
+			j++
+		}
+		if j == len(unbound) {
+			return nb
+		}
+		model[unbound[j]] = true
 	}
-	return int(nb)
 }
 
 // countCurrentModels is called when a model was found.
@@ -895,7 +1288,7 @@ func (s *Solver) addCurrentModels(ch chan []bool) int {
 // there are actually 2 models currently: one with 2 set to true, the other with 2 set to false.
 func (s *Solver) countCurrentModels() int {
 	var nb uint64 = 1 // total number of models found
-	for _, lvl := range s.lastModel {
+	for _, lvl := range s.lastModel[:s.nbUserVars] {
 		if lvl == 0 {
 			nb *= 2
 		}
@@ -910,7 +1303,7 @@ func (s *Solver) Optimal(results chan Result, stop chan struct{}) (res Result) {
 	if results != nil {
 		defer close(results)
 	}
-	status := s.Solve()
+	status := s.solveOrUseHint()
 	if status == Unsat { // Problem cannot be satisfied at all
 		res.Status = Unsat
 		if results != nil {
@@ -919,8 +1312,6 @@ func (s *Solver) Optimal(results chan Result, stop chan struct{}) (res Result) {
 		return res
 	}
 	if s.minLits == nil { // No optimization clause: this is a decision problem, solution is optimal
-		s.lastModel = make(Model, len(s.model))
-		copy(s.lastModel, s.model)
 		res := Result{
 			Status: Sat,
 			Model:  s.Model(),
@@ -947,20 +1338,9 @@ func (s *Solver) Optimal(results chan Result, stop chan struct{}) (res Result) {
 	weights := make([]int, len(s.minWeights))
 	copy(weights, s.minWeights)
 	sort.Sort(wLits{lits: s.hypothesis, weights: weights})
-	s.lastModel = make(Model, len(s.model))
 	var cost int
 	for status == Sat {
-		copy(s.lastModel, s.model) // Save this model: it might be the last one
-		cost = 0
-		for i, lit := range s.minLits {
-			if s.model[lit.Var()] > 0 == lit.IsPositive() {
-				if s.minWeights == nil {
-					cost++
-				} else {
-					cost += s.minWeights[i]
-				}
-			}
-		}
+		cost = s.modelCost(s.lastModel)
 		res = Result{
 			Status: Sat,
 			Model:  s.Model(),
@@ -971,6 +1351,10 @@ func (s *Solver) Optimal(results chan Result, stop chan struct{}) (res Result) {
 			results <- res
 		}
 		if cost == 0 {
+			if s.proof != nil { // No better solution can exist
+				s.proofSolution(s.lastModel)
+				s.proofUnsat()
+			}
 			break
 		}
 		// Add a constraint incrementing current best cost
@@ -978,7 +1362,7 @@ func (s *Solver) Optimal(results chan Result, stop chan struct{}) (res Result) {
 		weights2 := make([]int, len(s.minWeights))
 		copy(lits2, s.hypothesis)
 		copy(weights2, weights)
-		s.AppendClause(NewPBClause(lits2, weights2, maxCost-cost+1))
+		s.appendBound(NewPBClause(lits2, weights2, maxCost-cost+1))
 		s.rebuildOrderHeap()
 		status = s.Solve()
 	}
@@ -991,7 +1375,7 @@ func (s *Solver) Optimal(results chan Result, stop chan struct{}) (res Result) {
 // If this function is called on a non-optimization problem, it will either return -1, or a cost of 0 associated with a
 // satisfying model (ie any model is an optimal model).
 func (s *Solver) Minimize() int {
-	status := s.Solve()
+	status := s.solveOrUseHint()
 	if status == Unsat { // Problem cannot be satisfied at all
 		return -1
 	}
@@ -1013,21 +1397,14 @@ func (s *Solver) Minimize() int {
 	weights := make([]int, len(s.minWeights))
 	copy(weights, s.minWeights)
 	sort.Sort(wLits{lits: s.hypothesis, weights: weights})
-	s.lastModel = make(Model, len(s.model))
 	var cost int
 	for status == Sat {
-		copy(s.lastModel, s.model) // Save this model: it might be the last one
-		cost = 0
-		for i, lit := range s.minLits {
-			if s.model[lit.Var()] > 0 == lit.IsPositive() {
-				if s.minWeights == nil {
-					cost++
-				} else {
-					cost += s.minWeights[i]
-				}
-			}
-		}
+		cost = s.modelCost(s.lastModel)
 		if cost == 0 {
+			if s.proof != nil { // No better solution can exist
+				s.proofSolution(s.lastModel)
+				s.proofUnsat()
+			}
 			return 0
 		}
 		if s.Verbose {
@@ -1038,13 +1415,47 @@ func (s *Solver) Minimize() int {
 		weights2 := make([]int, len(s.minWeights))
 		copy(lits2, s.hypothesis)
 		copy(weights2, weights)
-		s.AppendClause(NewPBClause(lits2, weights2, maxCost-cost+1))
+		s.appendBound(NewPBClause(lits2, weights2, maxCost-cost+1))
 		s.rebuildOrderHeap()
 		status = s.Solve()
 	}
 	return cost
 }
 
+// appendBound appends the constraint c, stating that the cost of the next models must be lower than the cost of the last one.
+// If a proof is being logged, the last model is logged as a solution, which yields c.
+func (s *Solver) appendBound(c *Clause) {
+	if s.proof == nil {
+		s.AppendClause(c)
+		return
+	}
+	id := s.proofSolution(s.lastModel)
+	orig := PBConstr{Lits: make([]int, c.Len()), Weights: make([]int, c.Len()), AtLeast: c.Cardinality()}
+	for i, lit := range c.lits {
+		orig.Lits[i] = int(lit.Int())
+		orig.Weights[i] = c.Weight(i)
+	}
+	s.AppendClause(c)
+	if last := len(s.wl.origClauses) - 1; last >= 0 && s.wl.origClauses[last] == c {
+		s.proofRestrict(c, id, orig)
+	}
+}
+
+// modelCost returns the cost of the given model, according to the optimization function.
+func (s *Solver) modelCost(model Model) int {
+	cost := 0
+	for i, lit := range s.minLits {
+		if model[lit.Var()] > 0 == lit.IsPositive() {
+			if s.minWeights == nil {
+				cost++
+			} else {
+				cost += s.minWeights[i]
+			}
+		}
+	}
+	return cost
+}
+
 // functions to sort hypothesis for pseudo-boolean minimization clause.
 type wLits struct {
 	lits    []Lit
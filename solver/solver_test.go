@@ -2,9 +2,12 @@ package solver
 
 import (
 	"fmt"
+	"math/big"
 	"os"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 )
 
 // A test associates a path with an expected output.
@@ -145,6 +148,43 @@ func TestIntsToLits(t *testing.T) {
 	}
 }
 
+func TestAppendClauseUnsat(t *testing.T) {
+	for _, last := range [][]int32{{-2}, {-1, -2}} {
+		s := New(ParseSlice([][]int{{1, 2}, {1}}))
+		s.AppendClause(NewClause(IntsToLits(2)))
+		s.AppendClause(NewClause(IntsToLits(last...)))
+		if status := s.Assume(nil); status != Unsat {
+			t.Errorf("after appending %v: expected unsat after Assume, got %v", last, status)
+		}
+		if status := s.Solve(); status != Unsat {
+			t.Errorf("after appending %v: expected unsat, got %v", last, status)
+		}
+	}
+}
+
+func TestAppendClauseAfterSelectors(t *testing.T) {
+	s := New(ParseSlice([][]int{{1, 2}, {-1, 3}}))
+	for i := 0; i < 10; i++ {
+		s.TopK(2, nil)
+		s.EnumerateCubes(nil, false, nil)
+	}
+	if s.nbVars < 5 {
+		t.Fatalf("expected selectors to use indices of new vars, only got %d vars", s.nbVars)
+	}
+	// x4 and x5 reuse indices of selectors, x8 might not.
+	s.AppendClause(NewClause(IntsToLits(4, -5)))
+	s.AppendClause(NewClause(IntsToLits(-8, 5)))
+	if status := s.Solve(); status != Sat {
+		t.Errorf("expected SAT, got %v", status)
+	} else if model := s.Model(); len(model) != 8 {
+		t.Errorf("expected model with 8 vars, got %v", model)
+	}
+	// 4 models for x1..x3, 4 for x4, x5 and x8, and x6 and x7 are free.
+	if nb := s.CountModels(); nb != 4*4*4 {
+		t.Errorf("expected %d models, got %d", 4*4*4, nb)
+	}
+}
+
 func TestParseSliceTrivial(t *testing.T) {
 	cnf := [][]int{{1}, {-1}}
 	pb := ParseSlice(cnf)
@@ -514,3 +554,286 @@ func BenchmarkSolver11PigeonsPB(b *testing.B) {
 func BenchmarkSolver11PigeonsPBCP(b *testing.B) {
 	runBenchPB("testcnf/11-pigeons.opb", true, b)
 }
+
+func TestBackbone(t *testing.T) {
+	pb := ParseSlice([][]int{{1, 2}, {-1, 3}, {-2, 3}, {4}, {-5, -3}, {6, 7, 8}})
+	s := New(pb)
+	check := func(expected []int) {
+		t.Helper()
+		backbone := s.Backbone()
+		if len(backbone) != len(expected) {
+			t.Fatalf("invalid backbone: expected %v, got %v", expected, backbone)
+		}
+		for i, lit := range backbone {
+			if lit.Int() != int32(expected[i]) {
+				t.Fatalf("invalid backbone: expected %v, got %v", expected, backbone)
+			}
+		}
+	}
+	check([]int{3, 4, -5})
+	s.Assume(IntsToLits(-1))
+	check([]int{-1, 2, 3, 4, -5})
+	if s.Solve() != Sat {
+		t.Errorf("expected problem to be sat after backbone computation")
+	}
+	s.Assume(IntsToLits(-1, -2))
+	if backbone := s.Backbone(); backbone != nil {
+		t.Errorf("expected nil backbone for UNSAT problem, got %v", backbone)
+	}
+}
+
+func TestBackboneAfterSelectors(t *testing.T) {
+	s := New(ParseSlice([][]int{{1, 2}, {-1, 3}}))
+	for i := 0; i < 3; i++ {
+		s.EnumerateCubes(nil, false, nil)
+		s.TopK(2, nil)
+	}
+	if backbone := s.Backbone(); backbone == nil || len(backbone) != 0 {
+		t.Errorf("expected empty backbone, got %v", backbone)
+	}
+}
+
+func TestSolveAfterFailedAssumption(t *testing.T) {
+	// Each of these methods ends up assuming a literal that is already false at the top level.
+	calls := map[string]func(s *Solver){
+		"TopK":               func(s *Solver) { s.TopK(2, nil) },
+		"Closest":            func(s *Solver) { s.Closest(IntsToLits(2), nil) },
+		"EnumerateProjected": func(s *Solver) { s.EnumerateProjected(nil, nil, nil) },
+		"EnumerateCubes":     func(s *Solver) { s.EnumerateCubes(nil, false, nil) },
+		"EnumerateMinimal":   func(s *Solver) { s.EnumerateMinimal(nil, nil, nil) },
+	}
+	for name, call := range calls {
+		s := New(ParseSlice([][]int{{1}, {-2}}))
+		call(s)
+		if status := s.Solve(); status != Sat {
+			t.Errorf("expected SAT after %s, got %v", name, status)
+		}
+	}
+}
+
+func TestImplicant(t *testing.T) {
+	// x7 <=> x1 & x2, with x8 & x9 <=> x8 + x9 >= 2 as a cardinality constraint.
+	// Constraints are built for each solver, since parsing can modify their weights.
+	newSolver := func() *Solver {
+		return New(ParsePBConstrs([]PBConstr{
+			PropClause(-7, 1),
+			PropClause(-7, 2),
+			PropClause(7, -1, -2),
+			PropClause(7, 3, 4, 5, 6),
+			AtLeast([]int{8, 9}, 2),
+			GtEq([]int{1, 3, 10}, []int{1, 2, 3}, 3),
+		}))
+	}
+	s := newSolver()
+	s.SetHint([]bool{true, true, true, true, true, true, true, true, true, true})
+	if s.Solve() != Sat {
+		t.Fatalf("expected SAT")
+	}
+	isImplicant := func(lits []Lit) bool {
+		s2 := newSolver()
+		s2.Assume(lits)
+		return s2.Count().Cmp(big.NewInt(int64(1)<<uint(10-len(lits)))) == 0
+	}
+	imp := s.Implicant(nil)
+	if !isImplicant(imp) {
+		t.Errorf("%v is not an implicant", imp)
+	}
+	for i := range imp {
+		if smaller := append(append([]Lit{}, imp[:i]...), imp[i+1:]...); isImplicant(smaller) {
+			t.Errorf("implicant %v is not prime: %v is an implicant", imp, smaller)
+		}
+	}
+	var important []Var
+	for _, v := range []int{1, 2, 3, 4, 5, 6, 10} {
+		important = append(important, Var(v-1))
+	}
+	imp = s.Implicant(important)
+	for _, lit := range imp {
+		if v := lit.Var().Int(); v == 7 || v == 8 || v == 9 {
+			t.Errorf("unexpected lit %d in implicant %v", lit.Int(), imp)
+		}
+	}
+	if len(imp) != 3 {
+		t.Errorf("expected an implicant of size 3, got %v", imp)
+	}
+}
+
+func TestMinimalModels(t *testing.T) {
+	// Diagnosis of a system with 3 components, where abnormality predicates are 1, 2 and 3.
+	// Observations tell that either 1 is abnormal, or 2 and 3 are, or 2 is abnormal and 4 holds.
+	// Minimal diagnoses are thus {1} and {2}.
+	pb := ParseSlice([][]int{{1, 5}, {-5, 2}, {-5, 6}, {-6, 3, 4}, {-4, 2}})
+	abnormal := []Var{0, 1, 2}
+	s := New(pb)
+	for _, v := range abnormal {
+		s.polarity[v] = true
+	}
+	model := s.MinimalModel(abnormal)
+	if model == nil {
+		t.Fatalf("expected a minimal model")
+	}
+	if diag := fmt.Sprint(model[:3]); diag != "[true false false]" && diag != "[false true false]" {
+		t.Errorf("model %v is not minimal", model)
+	}
+	models := make(chan []bool)
+	diagnoses := make(map[string]bool)
+	done := make(chan struct{})
+	go func() {
+		for model := range models {
+			diagnoses[fmt.Sprint(model[:3])] = true
+		}
+		close(done)
+	}()
+	if nb := s.EnumerateMinimal(abnormal, models, nil); nb != 2 {
+		t.Errorf("expected 2 minimal models, got %d", nb)
+	}
+	<-done
+	if !diagnoses["[true false false]"] || !diagnoses["[false true false]"] {
+		t.Errorf("invalid minimal models %v", diagnoses)
+	}
+	for _, v := range abnormal {
+		if !s.polarity[v] {
+			t.Errorf("polarity of var %d was not restored", v.Int())
+		}
+	}
+	if s.Solve() != Sat {
+		t.Errorf("expected problem to be SAT after enumeration")
+	}
+	if model := New(ParseSlice([][]int{{1}, {-1}})).MinimalModel(abnormal); model != nil {
+		t.Errorf("expected nil model for UNSAT problem, got %v", model)
+	}
+}
+
+func TestLexModel(t *testing.T) {
+	pb := ParseSlice([][]int{{1, 2}, {-1, -2}, {2, 3}})
+	tests := []struct {
+		max      bool
+		order    []Var
+		expected string
+	}{
+		{false, nil, "[false true false]"},
+		{true, nil, "[true false true]"},
+		{false, []Var{2, 1, 0}, "[false true false]"},
+		{true, []Var{1, 0, 2}, "[false true true]"},
+	}
+	s := New(pb)
+	for _, test := range tests {
+		var model []bool
+		if test.max {
+			model = s.LexMax(test.order)
+		} else {
+			model = s.LexMin(test.order)
+		}
+		if res := fmt.Sprint(model); res != test.expected {
+			t.Errorf("max=%t, order %v: expected %s, got %s", test.max, test.order, test.expected, res)
+		}
+	}
+	s = New(pb)
+	s.LexMax(nil)
+	for v, pol := range s.polarity {
+		if pol {
+			t.Errorf("polarity of var %d was not restored", v+1)
+		}
+	}
+	if model := New(ParseSlice([][]int{{1}, {-1}})).LexMin(nil); model != nil {
+		t.Errorf("expected nil model for UNSAT problem, got %v", model)
+	}
+}
+
+func TestFailedAssumptions(t *testing.T) {
+	// Clause i is active iff its selector, i.e var i+4, is true
+	pb := ParseSlice([][]int{{1, -4}, {-1, -5}, {2, -6}, {3, -7}})
+	s := New(pb)
+	all := []Lit{IntToLit(4), IntToLit(5), IntToLit(6), IntToLit(7)}
+	if status := s.Assume(all); status != Unsat && s.Solve() != Unsat {
+		t.Fatalf("expected UNSAT under assumptions")
+	}
+	var core []int
+	for _, lit := range s.FailedAssumptions() {
+		core = append(core, int(lit.Int()))
+	}
+	sort.Ints(core)
+	if res := fmt.Sprint(core); res != "[4 5]" {
+		t.Errorf("expected failed assumptions [4 5], got %s", res)
+	}
+	if s.Assume(all[1:]) == Unsat || s.Solve() != Sat {
+		t.Fatalf("expected SAT under assumptions")
+	}
+	if core := s.FailedAssumptions(); len(core) != 0 {
+		t.Errorf("expected no failed assumptions, got %v", core)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	// Pigeonhole problem: 7 pigeons, 6 holes
+	const nbPigeons, nbHoles = 7, 6
+	var clauses [][]int
+	for p := 0; p < nbPigeons; p++ {
+		clause := make([]int, nbHoles)
+		for h := range clause {
+			clause[h] = p*nbHoles + h + 1
+		}
+		clauses = append(clauses, clause)
+	}
+	for h := 0; h < nbHoles; h++ {
+		for p1 := 0; p1 < nbPigeons; p1++ {
+			for p2 := p1 + 1; p2 < nbPigeons; p2++ {
+				clauses = append(clauses, []int{-(p1*nbHoles + h + 1), -(p2*nbHoles + h + 1)})
+			}
+		}
+	}
+	s := New(ParseSlice(clauses))
+	s.SetBudget(10, time.Time{})
+	if status := s.Solve(); status != Indet {
+		t.Fatalf("expected Indet after 10 conflicts, got %v", status)
+	}
+	s.SetBudget(0, time.Now().Add(-time.Second))
+	if status := s.Solve(); status != Indet {
+		t.Fatalf("expected Indet after deadline, got %v", status)
+	}
+	s.SetBudget(0, time.Time{})
+	if status := s.Solve(); status != Unsat {
+		t.Errorf("expected Unsat without budget, got %v", status)
+	}
+}
+
+// evalGate returns the value of the circuit g under the given model.
+func evalGate(g *Gate, model []bool) bool {
+	switch g.Op {
+	case GateFalse:
+		return false
+	case GateTrue:
+		return true
+	case GateLit:
+		return model[g.Lit.Var()] == g.Lit.IsPositive()
+	case GateAnd:
+		return evalGate(g.Inputs[0], model) && evalGate(g.Inputs[1], model)
+	default:
+		return evalGate(g.Inputs[0], model) || evalGate(g.Inputs[1], model)
+	}
+}
+
+func TestInterpolant(t *testing.T) {
+	// Only vars 2 and 3 are shared, and A is equivalent to 2 ∧ 3 on them
+	a := [][]int{{1}, {-1, 2}, {-1, 4}, {-4, 3}}
+	b := [][]int{{-2, -3, 5}, {-5, 6}, {-6}}
+	for _, system := range []InterpolationSystem{McMillan, Pudlak} {
+		g, err := Interpolant(a, b, system)
+		if err != nil {
+			t.Fatalf("could not compute interpolant with system %d: %v", system, err)
+		}
+		model := make([]bool, 6)
+		for x := 0; x < 1<<6; x++ {
+			for v := range model {
+				model[v] = x&(1<<v) != 0
+			}
+			if (model[1] && model[2]) != evalGate(g, model) {
+				t.Errorf("invalid interpolant with system %d: %v", system, g)
+				break
+			}
+		}
+	}
+	if _, err := Interpolant(a, [][]int{{-2, 3}}, McMillan); err == nil {
+		t.Errorf("expected an error for SAT problem")
+	}
+}
@@ -0,0 +1,90 @@
+package solver
+
+// TopK returns the k best models of the problem, i.e the k models with the lowest cost, in increasing cost order.
+// Models are distinct with respect to vars: two models that only differ on variables that are not in vars
+// are considered as the same solution, and only one of them will be returned.
+// If vars is nil, all the variables of the problem are considered.
+// Vars that appear several times in vars are only considered once.
+// If the problem has less than k distinct models, all of them are returned.
+// If the problem is not an optimization problem, all models have a cost of 0 and TopK returns k arbitrary distinct models.
+// The models are found by repeatedly optimizing the problem, each time blocking the models that were already returned.
+// Blocking clauses and cost bounds are only active during the call, so the solver can be used as usual afterwards.
+func (s *Solver) TopK(k int, vars []Var) []Result {
+	if k <= 0 || s.trivialUnsat {
+		return nil
+	}
+	if vars == nil {
+		vars = make([]Var, s.nbUserVars)
+		for i := range vars {
+			vars[i] = Var(i)
+		}
+	} else {
+		vars = uniqueVars(vars)
+	}
+	block := s.newSelector() // Blocking clauses are only active when block is true
+	var res []Result
+	for len(res) < k {
+		r := s.optimalUnder([]Lit{block})
+		if r.Status != Sat {
+			break
+		}
+		res = append(res, r)
+		lits := make([]Lit, len(vars)+1)
+		for i, v := range vars {
+			lits[i] = v.SignedLit(r.Model[v])
+		}
+		lits[len(vars)] = block.Negation()
+		s.AppendClause(NewClause(lits))
+	}
+	s.disableSelector(block)
+	return res
+}
+
+// optimalUnder returns an optimal model for the problem under the given assumptions,
+// or a result with the Unsat status if there is none.
+// Contrary to Optimal, it does not constrain the problem for good: each bound on the cost
+// is only active when a fresh selector is true, and that selector is disabled before returning.
+// The selector is only created if a bound is actually needed.
+func (s *Solver) optimalUnder(assumptions []Lit) Result {
+	var sel Lit
+	assumed := assumptions
+	res := Result{Status: Unsat}
+	s.Assume(assumed)
+	for s.Solve() == Sat {
+		cost := s.modelCost(s.lastModel)
+		res = Result{Status: Sat, Model: s.Model(), Weight: cost}
+		if s.minLits == nil || cost == 0 {
+			break
+		}
+		if len(assumed) == len(assumptions) {
+			sel = s.newSelector()
+			assumed = append(append([]Lit{}, assumptions...), sel)
+		}
+		s.AppendClause(s.boundClause(cost, sel))
+		s.Assume(assumed)
+	}
+	if len(assumed) != len(assumptions) {
+		s.disableSelector(sel)
+	}
+	return res
+}
+
+// boundClause returns a PB constraint stating the cost of a model must be strictly smaller than cost,
+// unless sel is false.
+func (s *Solver) boundClause(cost int, sel Lit) *Clause {
+	lits := make([]Lit, len(s.minLits)+1)
+	weights := make([]int, len(s.minLits)+1)
+	maxCost := 0
+	for i, lit := range s.minLits {
+		lits[i] = lit.Negation()
+		weights[i] = 1
+		if s.minWeights != nil {
+			weights[i] = s.minWeights[i]
+		}
+		maxCost += weights[i]
+	}
+	card := maxCost - cost + 1
+	lits[len(s.minLits)] = sel.Negation()
+	weights[len(s.minLits)] = card
+	return NewPBClause(lits, weights, card)
+}
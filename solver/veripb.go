@@ -0,0 +1,202 @@
+package solver
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A proof is a VeriPB proof being logged by a solver.
+// Each constraint of the proof is identified by its rank: constraints from the formula come first,
+// then each logged step that derives a constraint.
+type proof struct {
+	w      io.Writer
+	nextID int             // ID of the next derived constraint
+	ids    map[*Clause]int // ID of each constraint of the solver
+	units  map[Lit]int     // ID of each unit that was logged
+	unsat  bool            // True iff the contradiction was logged
+}
+
+// LogVeriPB makes the solver write, on w, a proof of its answers in the VeriPB format (version 1.2),
+// so that they can be checked independently by the VeriPB proof checker, given the formula the problem was built from,
+// i.e the OPB file given to ParseOPB, or the formula written by WriteFormula.
+// The proof contains:
+//   - learned clauses and units, that are checked by reverse unit propagation;
+//   - when s.CuttingPlanes is true, the cutting planes derivation of each learned constraint, i.e the additions,
+//     weakenings, divisions and saturations performed during conflict analysis;
+//   - solutions found by Optimal and Minimize, each of them yielding a constraint stating the cost must be improved;
+//   - the final contradiction, when the problem is UNSAT or when, for an optimization problem, no better solution exists:
+//     the last solution is then proven optimal.
+//
+// LogVeriPB must be called before solving the problem. Proofs can only be logged for problems built with ParseOPB,
+// ParsePBConstrs, ParseSlice or ParseCNF, and are only valid if no assumption is made and no clause is appended to the solver.
+// Constraints that cannot be traced back to the formula, such as appended clauses, are not part of the proof,
+// and neither are the cutting planes derivations that use them.
+// If the formula the problem was built from is unknown, an error is returned.
+func (s *Solver) LogVeriPB(w io.Writer) error {
+	if s.formula == nil {
+		return fmt.Errorf("cannot log proof: formula the problem was built from is unknown")
+	}
+	s.proof = &proof{
+		w:      w,
+		nextID: len(s.formula) + 1,
+		ids:    make(map[*Clause]int),
+		units:  make(map[Lit]int),
+	}
+	fmt.Fprintf(w, "pseudo-Boolean proof version 1.2\n")
+	fmt.Fprintf(w, "f %d\n", len(s.formula))
+	for _, c := range s.wl.origClauses {
+		if i, ok := s.origins[c]; ok {
+			s.proofRestrict(c, i+1, s.formula[i])
+		}
+	}
+	return nil
+}
+
+// WriteFormula writes, on w, the formula the problem was built from, in the OPB format, along with the cost function, if any.
+// This is the formula proofs logged with LogVeriPB refer to. This is useful when the problem was not read from an OPB file,
+// such as MAXSAT problems.
+// If the formula is unknown, an error is returned.
+func (s *Solver) WriteFormula(w io.Writer) error {
+	if s.formula == nil {
+		return fmt.Errorf("formula the problem was built from is unknown")
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "* #variable= %d #constraint= %d\n", s.nbVars, len(s.formula))
+	if s.minLits != nil {
+		fmt.Fprintf(&sb, "min: %s ;\n", proofTerms(s.minLits, s.minWeights))
+	}
+	for _, constr := range s.formula {
+		lits := make([]Lit, len(constr.Lits))
+		for i, val := range constr.Lits {
+			lits[i] = IntToLit(int32(val))
+		}
+		fmt.Fprintf(&sb, "%s >= %d ;\n", proofTerms(lits, constr.Weights), constr.AtLeast)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// proofVar returns the name of v in the proof.
+func proofVar(v Var) string {
+	return "x" + strconv.Itoa(int(v.Int()))
+}
+
+// proofLit returns the name of lit in the proof.
+func proofLit(lit Lit) string {
+	if lit.IsPositive() {
+		return proofVar(lit.Var())
+	}
+	return "~" + proofVar(lit.Var())
+}
+
+// proofTerms returns the terms of a constraint, in the OPB format.
+// If weights is nil, all weights are 1.
+func proofTerms(lits []Lit, weights []int) string {
+	terms := make([]string, len(lits))
+	for i, lit := range lits {
+		w := 1
+		if weights != nil {
+			w = weights[i]
+		}
+		terms[i] = fmt.Sprintf("%d %s", w, proofLit(lit))
+	}
+	return strings.Join(terms, " ")
+}
+
+// proofStep logs a step that derives a new constraint and returns the ID of that constraint.
+func (s *Solver) proofStep(format string, args ...interface{}) int {
+	fmt.Fprintf(s.proof.w, format+"\n", args...)
+	s.proof.nextID++
+	return s.proof.nextID - 1
+}
+
+// proofRUP logs the given constraint, that must be implied by reverse unit propagation, and returns its ID.
+// If weights is nil, all weights are 1.
+func (s *Solver) proofRUP(lits []Lit, weights []int, card int) int {
+	return s.proofStep("u %s >= %d ;", proofTerms(lits, weights), card)
+}
+
+// proofDerive logs the cutting planes derivation given in reverse polish notation and returns the ID
+// of the derived constraint. If no operation is performed, the ID of the only constraint is returned.
+func (s *Solver) proofDerive(expr []string) int {
+	if len(expr) == 1 {
+		id, _ := strconv.Atoi(expr[0])
+		return id
+	}
+	return s.proofStep("p %s", strings.Join(expr, " "))
+}
+
+// proofUnit returns the ID of the constraint stating lit, that must be true at the top level, is true.
+// If it was not logged yet, it is logged, as it can be checked by unit propagation.
+func (s *Solver) proofUnit(lit Lit) int {
+	if id, ok := s.proof.units[lit]; ok {
+		return id
+	}
+	id := s.proofRUP([]Lit{lit}, nil, 1)
+	s.proof.units[lit] = id
+	return id
+}
+
+// proofLearned logs the learned clause c, that can be checked by reverse unit propagation.
+func (s *Solver) proofLearned(c *Clause) {
+	s.proof.ids[c] = s.proofRUP(c.lits, nil, 1)
+}
+
+// proofRestrict associates c with the constraint with the given ID, whose content is orig,
+// c being orig deprived of the lits that are bound at the top level.
+// If c is different from orig, it is derived from it: true lits are weakened, and false lits are removed
+// by adding their negation, as many times as their weight. Clauses are saturated, in case they had duplicate lits.
+func (s *Solver) proofRestrict(c *Clause, id int, orig PBConstr) {
+	in := make(map[Lit]bool, c.Len())
+	for _, lit := range c.lits {
+		in[lit] = true
+	}
+	expr := []string{strconv.Itoa(id)}
+	for i, val := range orig.Lits {
+		lit := IntToLit(int32(val))
+		if in[lit] {
+			continue
+		}
+		w := 1
+		if orig.Weights != nil {
+			w = orig.Weights[i]
+		}
+		switch s.litStatus(lit) {
+		case Sat:
+			expr = append(expr, proofVar(lit.Var()), "w")
+		case Unsat:
+			expr = append(expr, strconv.Itoa(s.proofUnit(lit.Negation())))
+			if w != 1 {
+				expr = append(expr, strconv.Itoa(w), "*")
+			}
+			expr = append(expr, "+")
+		}
+	}
+	if !c.PseudoBoolean() && len(orig.Lits) != c.Len() {
+		expr = append(expr, "s")
+	}
+	s.proof.ids[c] = s.proofDerive(expr)
+}
+
+// proofSolution logs the given model as a solution that improves the cost, and returns the ID of the constraint
+// stating that the cost of the next solutions must be lower than the cost of model.
+func (s *Solver) proofSolution(model Model) int {
+	lits := make([]string, len(model))
+	for i, lvl := range model {
+		lits[i] = proofLit(Var(i).SignedLit(lvl < 0))
+	}
+	return s.proofStep("o %s", strings.Join(lits, " "))
+}
+
+// proofUnsat logs the contradiction, if it was not logged yet.
+// The problem must be UNSAT because of top-level bindings, so that the contradiction can be checked by unit propagation.
+func (s *Solver) proofUnsat() {
+	if s.proof.unsat {
+		return
+	}
+	id := s.proofRUP(nil, nil, 1)
+	fmt.Fprintf(s.proof.w, "c %d\n", id)
+	s.proof.unsat = true
+}
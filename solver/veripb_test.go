@@ -0,0 +1,590 @@
+package solver
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// A proofConstr is a constraint, as seen by proofChecker.
+// Coefficients are indexed by var; a negative coefficient applies to the negation of the var.
+type proofConstr struct {
+	coeffs map[Var]int
+	degree int
+}
+
+func newProofConstr(lits []int, weights []int, degree int) proofConstr {
+	c := proofConstr{coeffs: make(map[Var]int), degree: degree}
+	for i, val := range lits {
+		w := 1
+		if weights != nil {
+			w = weights[i]
+		}
+		if val < 0 {
+			w = -w
+		}
+		c = c.add(proofConstr{coeffs: map[Var]int{IntToLit(int32(val)).Var(): w}})
+	}
+	return c
+}
+
+func (c proofConstr) add(c2 proofConstr) proofConstr {
+	res := proofConstr{coeffs: make(map[Var]int), degree: c.degree + c2.degree}
+	for v, w := range c.coeffs {
+		res.coeffs[v] = w
+	}
+	for v, w2 := range c2.coeffs {
+		w1 := res.coeffs[v]
+		if w1*w2 < 0 {
+			res.degree -= min(abs(w1), abs(w2))
+		}
+		res.coeffs[v] = w1 + w2
+		if res.coeffs[v] == 0 {
+			delete(res.coeffs, v)
+		}
+	}
+	return res
+}
+
+func ceilDiv(a, b int) int {
+	if a <= 0 {
+		return -(-a / b)
+	}
+	return (a + b - 1) / b
+}
+
+// slack returns the slack of c under the given assignment, where each var is associated with 1, -1 or 0.
+func (c proofConstr) slack(assign map[Var]int) int {
+	res := -c.degree
+	for v, w := range c.coeffs {
+		if assign[v] == 0 || (assign[v] > 0) == (w > 0) {
+			res += abs(w)
+		}
+	}
+	return res
+}
+
+// A proofChecker checks VeriPB proofs, in a simplified way.
+type proofChecker struct {
+	db          map[int]proofConstr
+	nextID      int
+	occurs      map[Var][]int // IDs of the constraints each var appears in
+	roots       []int         // IDs of the constraints that propagate or conflict without any assignment
+	formula     []proofConstr
+	objLits     []Lit
+	objWeights  []int
+	nbSolutions int
+	concluded   bool
+}
+
+// propagate returns true iff unit propagation on all constraints and on extra leads to a conflict.
+func (pc *proofChecker) propagate(extra proofConstr) bool {
+	assign := make(map[Var]int)
+	queue := append([]int{0}, pc.roots...) // 0 is the ID of extra
+	for len(queue) > 0 {
+		c := extra
+		if queue[0] != 0 {
+			c = pc.db[queue[0]]
+		}
+		queue = queue[1:]
+		slack := c.slack(assign)
+		if slack < 0 {
+			return true
+		}
+		for v, w := range c.coeffs {
+			if assign[v] == 0 && abs(w) > slack {
+				assign[v] = w
+				queue = append(queue, pc.occurs[v]...)
+				if _, ok := extra.coeffs[v]; ok {
+					queue = append(queue, 0)
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (pc *proofChecker) parseConstr(fields []string) (proofConstr, error) {
+	if len(fields) < 3 || fields[len(fields)-1] != ";" || fields[len(fields)-3] != ">=" {
+		return proofConstr{}, fmt.Errorf("invalid constraint %q", fields)
+	}
+	degree, err := strconv.Atoi(fields[len(fields)-2])
+	if err != nil {
+		return proofConstr{}, err
+	}
+	var pb Problem
+	weights, lits, err := pb.parseTerms(fields[:len(fields)-3], strings.Join(fields, " "))
+	if err != nil {
+		return proofConstr{}, err
+	}
+	return newProofConstr(lits, weights, degree), nil
+}
+
+func (pc *proofChecker) step(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] == "*" || strings.HasPrefix(fields[0], "*") {
+		return nil
+	}
+	var c proofConstr
+	switch fields[0] {
+	case "pseudo-Boolean":
+		return nil
+	case "f":
+		if nb, err := strconv.Atoi(fields[1]); err != nil || nb != len(pc.formula) {
+			return fmt.Errorf("invalid formula size in %q", line)
+		}
+		for _, c := range pc.formula {
+			pc.add(c)
+		}
+		return nil
+	case "c":
+		id, _ := strconv.Atoi(fields[1])
+		c, ok := pc.db[id]
+		if !ok || c.slack(nil) >= 0 {
+			return fmt.Errorf("constraint %d is not a contradiction", id)
+		}
+		pc.concluded = true
+		return nil
+	case "u":
+		var err error
+		if c, err = pc.parseConstr(fields[1:]); err != nil {
+			return err
+		}
+		neg := proofConstr{coeffs: make(map[Var]int), degree: 1 - c.degree}
+		for v, w := range c.coeffs {
+			neg.coeffs[v] = -w
+			neg.degree += abs(w)
+		}
+		if !pc.propagate(neg) {
+			return fmt.Errorf("constraint is not RUP")
+		}
+	case "o":
+		assign := make(map[Var]int)
+		for _, name := range fields[1:] {
+			val, _ := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(name, "~"), "x"))
+			assign[Var(val-1)] = 1
+			if name[0] == '~' {
+				assign[Var(val-1)] = -1
+			}
+		}
+		for _, c := range pc.db {
+			if c.slack(assign) < 0 {
+				return fmt.Errorf("solution does not satisfy all constraints")
+			}
+		}
+		var lits, weights []int
+		sum, cost := 0, 0
+		for i, lit := range pc.objLits {
+			lits = append(lits, int(lit.Negation().Int()))
+			weights = append(weights, pc.objWeights[i])
+			sum += pc.objWeights[i]
+			if (assign[lit.Var()] > 0) == lit.IsPositive() {
+				cost += pc.objWeights[i]
+			}
+		}
+		c = newProofConstr(lits, weights, sum-cost+1)
+		pc.nbSolutions++
+	case "p":
+		var stack []proofConstr
+		for i := 1; i < len(fields); i++ {
+			tok := fields[i]
+			switch tok {
+			case "+":
+				stack = append(stack[:len(stack)-2], stack[len(stack)-2].add(stack[len(stack)-1]))
+			case "s":
+				top := stack[len(stack)-1]
+				for v, w := range top.coeffs {
+					if abs(w) > top.degree && top.degree > 0 {
+						if w > 0 {
+							top.coeffs[v] = top.degree
+						} else {
+							top.coeffs[v] = -top.degree
+						}
+					}
+				}
+			case "*", "d":
+				k, _ := strconv.Atoi(fields[i-1])
+				top := stack[len(stack)-1]
+				res := proofConstr{coeffs: make(map[Var]int)}
+				for v, w := range top.coeffs {
+					if tok == "*" {
+						res.coeffs[v] = w * k
+					} else if w > 0 {
+						res.coeffs[v] = ceilDiv(w, k)
+					} else {
+						res.coeffs[v] = -ceilDiv(-w, k)
+					}
+				}
+				if tok == "*" {
+					res.degree = top.degree * k
+				} else {
+					res.degree = ceilDiv(top.degree, k)
+				}
+				stack[len(stack)-1] = res
+			case "w":
+				val, _ := strconv.Atoi(strings.TrimPrefix(fields[i-1], "x"))
+				top := stack[len(stack)-1]
+				res := proofConstr{coeffs: make(map[Var]int), degree: top.degree}
+				for v, w := range top.coeffs {
+					if v == Var(val-1) {
+						res.degree -= abs(w)
+					} else {
+						res.coeffs[v] = w
+					}
+				}
+				stack[len(stack)-1] = res
+			default:
+				if i+1 < len(fields) && (fields[i+1] == "*" || fields[i+1] == "d" || fields[i+1] == "w") {
+					continue // Argument of the next operator
+				}
+				id, err := strconv.Atoi(tok)
+				if err != nil {
+					return fmt.Errorf("invalid token %q", tok)
+				}
+				c, ok := pc.db[id]
+				if !ok {
+					return fmt.Errorf("unknown constraint %d", id)
+				}
+				stack = append(stack, c)
+			}
+		}
+		if len(stack) != 1 {
+			return fmt.Errorf("invalid derivation")
+		}
+		c = stack[0]
+	default:
+		return fmt.Errorf("unknown rule %q", fields[0])
+	}
+	pc.add(c)
+	return nil
+}
+
+// add adds c to the database, with the next ID.
+func (pc *proofChecker) add(c proofConstr) {
+	pc.db[pc.nextID] = c
+	slack := c.slack(nil)
+	for v, w := range c.coeffs {
+		pc.occurs[v] = append(pc.occurs[v], pc.nextID)
+		if abs(w) > slack {
+			slack = -1
+		}
+	}
+	if slack < 0 {
+		pc.roots = append(pc.roots, pc.nextID)
+	}
+	pc.nextID++
+}
+
+// checkProof checks the given proof for the given OPB formula, and returns the number of solutions it logged
+// and whether it concluded with a contradiction.
+func checkProof(formula, proof string) (nbSolutions int, concluded bool, err error) {
+	pc := proofChecker{db: make(map[int]proofConstr), nextID: 1, occurs: make(map[Var][]int)}
+	var pb Problem
+	sc := bufio.NewScanner(strings.NewReader(formula))
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" || line[0] == '*' {
+			continue
+		}
+		fields := strings.Fields(line[:len(line)-1])
+		if fields[0] == "min:" {
+			if err := pb.parsePBOptim(fields, line); err != nil {
+				return 0, false, err
+			}
+			pc.objLits, pc.objWeights = pb.minLits, pb.minWeights
+			continue
+		}
+		constrs, err := pb.parsePBConstrs(fields, line)
+		if err != nil {
+			return 0, false, err
+		}
+		if fields[len(fields)-2] == "=" { // Both halves of equalities are numbered, even trivial ones
+			weights, lits, _ := pb.parseTerms(fields[:len(fields)-2], line)
+			rhs, _ := strconv.Atoi(fields[len(fields)-1])
+			lits2 := make([]int, len(lits))
+			weights2 := make([]int, len(weights))
+			copy(lits2, lits)
+			copy(weights2, weights)
+			constrs = []PBConstr{GtEq(lits2, weights2, rhs), LtEq(lits, weights, rhs)}
+		}
+		for _, c := range constrs {
+			pc.formula = append(pc.formula, newProofConstr(c.Lits, c.Weights, c.AtLeast))
+		}
+	}
+	sc = bufio.NewScanner(strings.NewReader(proof))
+	sc.Buffer(nil, 1<<24)
+	for i := 1; sc.Scan(); i++ {
+		if err := pc.step(sc.Text()); err != nil {
+			return 0, false, fmt.Errorf("line %d %q: %v", i, sc.Text(), err)
+		}
+		if pc.concluded {
+			break
+		}
+	}
+	return pc.nbSolutions, pc.concluded, nil
+}
+
+// pigeonholeOPB returns an OPB formula stating n pigeons fit in n-1 holes.
+func pigeonholeOPB(n int) string {
+	var sb strings.Builder
+	x := func(p, h int) int { return p*(n-1) + h + 1 }
+	for p := 0; p < n; p++ {
+		for h := 0; h < n-1; h++ {
+			fmt.Fprintf(&sb, "+1 x%d ", x(p, h))
+		}
+		sb.WriteString(">= 1 ;\n")
+	}
+	for h := 0; h < n-1; h++ {
+		for p := 0; p < n; p++ {
+			fmt.Fprintf(&sb, "-1 x%d ", x(p, h))
+		}
+		sb.WriteString(">= -1 ;\n")
+	}
+	return sb.String()
+}
+
+// pigeonholeCNF returns a CNF formula stating n pigeons fit in n-1 holes.
+func pigeonholeCNF(n int) string {
+	var sb strings.Builder
+	x := func(p, h int) int { return p*(n-1) + h + 1 }
+	fmt.Fprintf(&sb, "p cnf %d %d\n", n*(n-1), n+(n-1)*n*(n-1)/2)
+	for p := 0; p < n; p++ {
+		for h := 0; h < n-1; h++ {
+			fmt.Fprintf(&sb, "%d ", x(p, h))
+		}
+		sb.WriteString("0\n")
+	}
+	for h := 0; h < n-1; h++ {
+		for p1 := 0; p1 < n; p1++ {
+			for p2 := p1 + 1; p2 < n; p2++ {
+				fmt.Fprintf(&sb, "-%d -%d 0\n", x(p1, h), x(p2, h))
+			}
+		}
+	}
+	return sb.String()
+}
+
+func TestVeriPBUnsat(t *testing.T) {
+	formulas := []string{
+		pigeonholeOPB(6),
+		// Two units, and a clause that is only falsified once they are propagated
+		`+1 x1 >= 1 ;
++1 x2 >= 1 ;
++1 ~x1 +1 ~x2 >= 1 ;
+`,
+	}
+	for i, formula := range formulas {
+		for _, cp := range []bool{false, true} {
+			pb, err := ParseOPB(strings.NewReader(formula))
+			if err != nil {
+				t.Fatalf("could not parse formula #%d: %v", i, err)
+			}
+			s := New(pb)
+			s.CuttingPlanes = cp
+			var proof bytes.Buffer
+			if err := s.LogVeriPB(&proof); err != nil {
+				t.Fatalf("could not log proof for formula #%d: %v", i, err)
+			}
+			if status := s.Solve(); status != Unsat {
+				t.Fatalf("expected UNSAT for formula #%d, got %v", i, status)
+			}
+			if _, concluded, err := checkProof(formula, proof.String()); err != nil {
+				t.Errorf("invalid proof for formula #%d with cutting planes=%t: %v", i, cp, err)
+			} else if !concluded {
+				t.Errorf("proof for formula #%d with cutting planes=%t does not conclude", i, cp)
+			}
+		}
+	}
+}
+
+func TestVeriPBCuttingPlanesDecisions(t *testing.T) {
+	// Conflict analysis backjumps over decisions before the derived constraint is asserting:
+	// it must stop at the asserting level, rather than walk down to the top level and claim UNSAT.
+	for _, n := range []int{8, 9} {
+		formula := pigeonholeOPB(n)
+		pb, err := ParseOPB(strings.NewReader(formula))
+		if err != nil {
+			t.Fatalf("could not parse formula for %d pigeons: %v", n, err)
+		}
+		s := New(pb)
+		s.CuttingPlanes = true
+		var proof bytes.Buffer
+		if err := s.LogVeriPB(&proof); err != nil {
+			t.Fatalf("could not log proof for %d pigeons: %v", n, err)
+		}
+		if status := s.Solve(); status != Unsat {
+			t.Fatalf("expected UNSAT for %d pigeons, got %v", n, status)
+		}
+		if _, concluded, err := checkProof(formula, proof.String()); err != nil {
+			t.Errorf("invalid proof for %d pigeons: %v", n, err)
+		} else if !concluded {
+			t.Errorf("proof for %d pigeons does not conclude", n)
+		}
+	}
+}
+
+func TestVeriPBAppendedClause(t *testing.T) {
+	// The first pigeon will only be placed by an appended clause, that is not part of the formula.
+	formula := pigeonholeOPB(6)
+	formula = formula[strings.Index(formula, "\n")+1:]
+	pb, err := ParseOPB(strings.NewReader(formula))
+	if err != nil {
+		t.Fatalf("could not parse formula: %v", err)
+	}
+	s := New(pb)
+	s.CuttingPlanes = true
+	var proof bytes.Buffer
+	if err := s.LogVeriPB(&proof); err != nil {
+		t.Fatalf("could not log proof: %v", err)
+	}
+	s.AppendClause(NewClause(IntsToLits(1, 2, 3, 4, 5)))
+	if status := s.Solve(); status != Unsat {
+		t.Fatalf("expected UNSAT, got %v", status)
+	}
+	for _, line := range strings.Split(proof.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "p" {
+			continue
+		}
+		for _, field := range fields[1:] {
+			if field == "0" {
+				t.Fatalf("derivation %q refers to a constraint that is not part of the proof", line)
+			}
+		}
+	}
+}
+
+func TestVeriPBOptimal(t *testing.T) {
+	tests := []struct {
+		formula string
+		cost    int
+	}{
+		{`* #variable= 6 #constraint= 4
+min: 4 x1 3 x2 5 x3 2 x4 6 x5 1 x6 ;
+3 x1 2 x2 4 x3 >= 5 ;
+2 x3 3 x4 x5 >= 3 ;
+1 x1 1 x4 1 x6 >= 2 ;
+2 x2 +1 ~x5 +3 x6 = 4 ;
+`, 12},
+		{`min: 2 x4 4 x5 3 x6 5 x8 1 x9 1 x10 ;
++2 x11 +1 ~x12 = 3 ;
++3 x6 +3 x4 +3 x10 +1 ~x3 >= 1 ;
++4 ~x7 +1 x6 +3 ~x8 >= 4 ;
++2 ~x2 +1 ~x5 +4 x10 +3 ~x1 +4 ~x8 >= 4 ;
++4 x6 +1 ~x5 +3 ~x2 >= 1 ;
++3 x3 +3 x5 >= 5 ;
+`, 5},
+	}
+	for i, test := range tests {
+		for _, cp := range []bool{false, true} {
+			pb, err := ParseOPB(strings.NewReader(test.formula))
+			if err != nil {
+				t.Fatalf("could not parse formula #%d: %v", i, err)
+			}
+			s := New(pb)
+			s.CuttingPlanes = cp
+			var proof bytes.Buffer
+			if err := s.LogVeriPB(&proof); err != nil {
+				t.Fatalf("could not log proof for formula #%d: %v", i, err)
+			}
+			res := s.Optimal(nil, nil)
+			if res.Status != Sat || res.Weight != test.cost {
+				t.Fatalf("expected optimal cost %d for formula #%d, got %v with cost %d", test.cost, i, res.Status, res.Weight)
+			}
+			nbSolutions, concluded, err := checkProof(test.formula, proof.String())
+			if err != nil {
+				t.Errorf("invalid proof for formula #%d with cutting planes=%t: %v", i, cp, err)
+			} else if !concluded || nbSolutions == 0 {
+				t.Errorf("proof for formula #%d with cutting planes=%t does not prove optimality: %d solutions, concluded=%t", i, cp, nbSolutions, concluded)
+			}
+		}
+	}
+}
+
+func TestVeriPBFormula(t *testing.T) {
+	pb := ParsePBConstrs([]PBConstr{
+		GtEq([]int{1, 2, 3}, []int{2, 1, 1}, 2),
+		AtMost([]int{1, 2, 3}, 1),
+		PropClause(-1, 4),
+	})
+	pb.SetCostFunc([]Lit{IntToLit(4), IntToLit(2)}, []int{3, 1})
+	s := New(pb)
+	var formula, proof bytes.Buffer
+	if err := s.WriteFormula(&formula); err != nil {
+		t.Fatalf("could not write formula: %v", err)
+	}
+	if err := s.LogVeriPB(&proof); err != nil {
+		t.Fatalf("could not log proof: %v", err)
+	}
+	if cost := s.Minimize(); cost != 3 {
+		t.Fatalf("expected cost 3, got %d", cost)
+	}
+	if _, concluded, err := checkProof(formula.String(), proof.String()); err != nil {
+		t.Errorf("invalid proof: %v", err)
+	} else if !concluded {
+		t.Errorf("proof does not prove optimality")
+	}
+	if err := New(ParseCardConstrs([]CardConstr{AtLeast1(1, 2)})).LogVeriPB(&proof); err == nil {
+		t.Errorf("expected an error for unknown formula")
+	}
+}
+
+func TestVeriPBFiles(t *testing.T) {
+	tests := []struct {
+		path string
+		cp   bool
+	}{
+		{"testcnf/ex1.opb", false},
+		{"testcnf/8-pigeons.opb", true},
+		{"testcnf/lo_8x8_009.opb", false},
+	}
+	for _, test := range tests {
+		data, err := os.ReadFile(test.path)
+		if err != nil {
+			t.Fatalf("could not read %q: %v", test.path, err)
+		}
+		pb, err := ParseOPB(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("could not parse %q: %v", test.path, err)
+		}
+		s := New(pb)
+		s.CuttingPlanes = test.cp
+		var proof bytes.Buffer
+		if err := s.LogVeriPB(&proof); err != nil {
+			t.Fatalf("could not log proof for %q: %v", test.path, err)
+		}
+		s.Optimal(nil, nil)
+		if _, concluded, err := checkProof(string(data), proof.String()); err != nil {
+			t.Errorf("invalid proof for %q: %v", test.path, err)
+		} else if !concluded {
+			t.Errorf("proof for %q does not conclude", test.path)
+		}
+	}
+}
+
+func TestVeriPBCNF(t *testing.T) {
+	pb, err := ParseCNF(strings.NewReader(pigeonholeCNF(5)))
+	if err != nil {
+		t.Fatalf("could not parse formula: %v", err)
+	}
+	s := New(pb)
+	var formula, proof bytes.Buffer
+	if err := s.WriteFormula(&formula); err != nil {
+		t.Fatalf("could not write formula: %v", err)
+	}
+	if err := s.LogVeriPB(&proof); err != nil {
+		t.Fatalf("could not log proof: %v", err)
+	}
+	if status := s.Solve(); status != Unsat {
+		t.Fatalf("expected UNSAT, got %v", status)
+	}
+	if _, concluded, err := checkProof(formula.String(), proof.String()); err != nil {
+		t.Errorf("invalid proof: %v", err)
+	} else if !concluded {
+		t.Errorf("proof does not conclude")
+	}
+}
@@ -204,11 +204,87 @@ func (s *Solver) reduceLearned() {
 		s.Stats.NbDeleted++
 		s.wl.learned[i] = s.wl.learned[nbLearned-nbRemoved]
 		s.unwatchClause(c)
+		if s.proof != nil { // Deleted constraints are not needed anymore
+			delete(s.proof.ids, c)
+		}
 	}
 	nbLearned -= nbRemoved
 	s.wl.learned = s.wl.learned[:nbLearned]
 }
 
+// removeSatisfied removes the problem and learned constraints that are satisfied by top-level bindings.
+// Constraints that are the reason of a binding are kept.
+// It must be called when all bindings are top-level ones, i.e when there are no assumptions and no decisions.
+func (s *Solver) removeSatisfied() {
+	s.removeConstraints(func(c *Clause) bool {
+		sum := 0
+		for i := 0; i < c.Len(); i++ {
+			if lit := c.Get(i); s.litStatus(lit) == Sat {
+				if s.reason[lit.Var()] == c {
+					return false
+				}
+				sum += c.Weight(i)
+			}
+		}
+		return sum >= c.Cardinality()
+	})
+}
+
+// removeConstraints removes the problem and learned constraints for which remove returns true.
+func (s *Solver) removeConstraints(remove func(c *Clause) bool) {
+	removed := make(map[*Clause]bool)
+	filter := func(clauses []*Clause) []*Clause {
+		j := 0
+		for _, c := range clauses {
+			if remove(c) {
+				removed[c] = true
+				if s.proof != nil { // Deleted constraints are not needed anymore
+					delete(s.proof.ids, c)
+				}
+			} else {
+				clauses[j] = c
+				j++
+			}
+		}
+		return clauses[:j]
+	}
+	s.wl.origClauses = filter(s.wl.origClauses)
+	s.wl.learned = filter(s.wl.learned)
+	if len(removed) == 0 {
+		return
+	}
+	for lit := range s.wl.wlist {
+		s.wl.wlistBin[lit] = removeWatchers(s.wl.wlistBin[lit], removed)
+		s.wl.wlist[lit] = removeWatchers(s.wl.wlist[lit], removed)
+		s.wl.wlistPb[lit] = removeClauses(s.wl.wlistPb[lit], removed)
+		s.wl.wlistCardAMO[lit] = removeClauses(s.wl.wlistCardAMO[lit], removed)
+	}
+}
+
+// removeWatchers removes from ws the watchers of the removed clauses.
+func removeWatchers(ws []watcher, removed map[*Clause]bool) []watcher {
+	j := 0
+	for _, w := range ws {
+		if !removed[w.clause] {
+			ws[j] = w
+			j++
+		}
+	}
+	return ws[:j]
+}
+
+// removeClauses removes from lst the removed clauses.
+func removeClauses(lst []*Clause, removed map[*Clause]bool) []*Clause {
+	j := 0
+	for _, c := range lst {
+		if !removed[c] {
+			lst[j] = c
+			j++
+		}
+	}
+	return lst[:j]
+}
+
 type watcherListPB watcherList // A type synonymous to sort PB constraints a little more efficiently.
 
 func (wl *watcherListPB) Len() int      { return len(wl.learned) }
@@ -233,6 +309,9 @@ func (s *Solver) reduceLearnedPB() {
 		s.Stats.NbDeleted++
 		s.wl.learned[i] = s.wl.learned[nbLearned-nbRemoved]
 		s.unwatchPB(c)
+		if s.proof != nil { // Deleted constraints are not needed anymore
+			delete(s.proof.ids, c)
+		}
 	}
 	nbLearned -= nbRemoved
 	s.wl.learned = s.wl.learned[:nbLearned]
@@ -255,7 +334,11 @@ func (s *Solver) addLearned(c *Clause) {
 
 // Adds the given unit literal to the model at the top level.
 func (s *Solver) addLearnedUnit(unit Lit) {
+	s.units = append(s.units, unit)
 	s.model[unit.Var()] = lvlToSignedLvl(unit, 1)
+	if s.proof != nil {
+		s.proofUnit(unit)
+	}
 	if s.Certified {
 		if s.CertChan == nil {
 			fmt.Printf("%d 0\n", unit.Int())
@@ -0,0 +1,141 @@
+package solver
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+)
+
+// A value is associated by the counter with a set of models: a number of models, or the sum of their weights.
+type value interface{}
+
+// A semiring defines how values are computed when counting models.
+// Implementations must never modify their arguments, since values are cached and shared by the counter.
+type semiring interface {
+	zero() value
+	one() value
+	lit(lit Lit) value // Value of a lit when it is bound to true
+	add(x, y value) value
+	mul(x, y value) value
+	isZero(x value) bool
+}
+
+// intRing is the semiring used to count models: values are *big.Int and all lits have a value of 1.
+type intRing struct{}
+
+var bigOne = big.NewInt(1)
+
+func (intRing) zero() value          { return new(big.Int) }
+func (intRing) one() value           { return bigOne }
+func (intRing) lit(lit Lit) value    { return bigOne }
+func (intRing) add(x, y value) value { return new(big.Int).Add(x.(*big.Int), y.(*big.Int)) }
+func (intRing) mul(x, y value) value { return new(big.Int).Mul(x.(*big.Int), y.(*big.Int)) }
+func (intRing) isZero(x value) bool  { return x.(*big.Int).Sign() == 0 }
+
+// floatRing is the semiring used for weighted model counting with float64 weights.
+type floatRing struct {
+	weights map[Lit]float64
+}
+
+func (floatRing) zero() value          { return 0.0 }
+func (floatRing) one() value           { return 1.0 }
+func (floatRing) add(x, y value) value { return x.(float64) + y.(float64) }
+func (floatRing) mul(x, y value) value { return x.(float64) * y.(float64) }
+func (floatRing) isZero(x value) bool  { return x.(float64) == 0 }
+
+func (r floatRing) lit(lit Lit) value {
+	if w, ok := r.weights[lit]; ok {
+		return w
+	}
+	return 1.0
+}
+
+// ratRing is the semiring used for weighted model counting with arbitrary-precision rational weights.
+type ratRing struct {
+	weights map[Lit]*big.Rat
+}
+
+var ratOne = big.NewRat(1, 1)
+
+func (ratRing) zero() value          { return new(big.Rat) }
+func (ratRing) one() value           { return ratOne }
+func (ratRing) add(x, y value) value { return new(big.Rat).Add(x.(*big.Rat), y.(*big.Rat)) }
+func (ratRing) mul(x, y value) value { return new(big.Rat).Mul(x.(*big.Rat), y.(*big.Rat)) }
+func (ratRing) isZero(x value) bool  { return x.(*big.Rat).Sign() == 0 }
+
+func (r ratRing) lit(lit Lit) value {
+	if w, ok := r.weights[lit]; ok {
+		return w
+	}
+	return ratOne
+}
+
+// WeightedCount returns the weighted model count of the problem, i.e the sum, over all models,
+// of the product of the weights of the literals that are true in the model.
+// Literals that do not appear in weights have a weight of 1.
+// As with Count, current top-level bindings, including assumptions, are taken into account,
+// and the state of the solver is not modified.
+func (s *Solver) WeightedCount(weights map[Lit]float64) float64 {
+	if s.status == Unsat {
+		return 0
+	}
+	c := newCounter(s, floatRing{weights: weights})
+	return c.count().(float64)
+}
+
+// WeightedCountRat is like WeightedCount, but weights are arbitrary-precision rationals,
+// so that the result is exact.
+func (s *Solver) WeightedCountRat(weights map[Lit]*big.Rat) *big.Rat {
+	if s.status == Unsat {
+		return new(big.Rat)
+	}
+	c := newCounter(s, ratRing{weights: weights})
+	return c.count().(*big.Rat)
+}
+
+// ParseWeightedCNF parses a CNF file with literal weights and returns the corresponding Problem
+// and the weight of each literal.
+// Weights are given in comment lines of the form "c p weight <lit> <weight> 0", as in the model counting competition.
+// Weights can be written as decimal numbers (e.g "0.25" or "1e-3") or fractions (e.g "1/4").
+// Literals that have no weight line have a weight of 1.
+func ParseWeightedCNF(f io.Reader) (*Problem, map[Lit]*big.Rat, error) {
+	weights := make(map[Lit]*big.Rat)
+	pb, err := parseCNFWithComments(f, func(line string, fields []string) error {
+		if len(fields) < 5 || fields[1] != "p" || fields[2] != "weight" {
+			return nil
+		}
+		if len(fields) > 6 || (len(fields) == 6 && fields[5] != "0") {
+			return fmt.Errorf("invalid weight line %q", line)
+		}
+		val, err := strconv.Atoi(fields[3])
+		if err != nil || val == 0 {
+			return fmt.Errorf("invalid literal in weight line %q", line)
+		}
+		w, ok := new(big.Rat).SetString(fields[4])
+		if !ok {
+			return fmt.Errorf("invalid weight in weight line %q", line)
+		}
+		weights[IntToLit(int32(val))] = w
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	for lit := range weights {
+		if int(lit.Var()) >= pb.NbVars {
+			return nil, nil, fmt.Errorf("invalid literal %d for problem with %d vars only", lit.Int(), pb.NbVars)
+		}
+	}
+	return pb, weights, nil
+}
+
+// FloatWeights converts rational weights, as returned by ParseWeightedCNF, to float64 weights,
+// as expected by WeightedCount.
+func FloatWeights(weights map[Lit]*big.Rat) map[Lit]float64 {
+	res := make(map[Lit]float64, len(weights))
+	for lit, w := range weights {
+		res[lit], _ = w.Float64()
+	}
+	return res
+}